@@ -0,0 +1,40 @@
+package geo
+
+import (
+	"math"
+)
+
+// LatitudeBand returns which of the equal width latitude bands
+// (counting up from the south pole) the latitude falls in,
+// or -1 if the latitude is outside -90..90 or there are no bands.
+//
+// Each band includes its southern edge, and the last also includes
+// the north pole, so the bands partition the globe
+func LatitudeBand(lat float64, bands int) int {
+	if bands < 1 || !(lat >= -90 && lat <= 90) {
+		return -1
+	}
+	band := int(math.Floor((lat + 90) * float64(bands) / 180))
+	if band >= bands {
+		band = bands - 1
+	}
+	// agree with BandBounds where rounding puts lat on the wrong side of an edge
+	if minLat, maxLat := BandBounds(band, bands); lat < minLat && band > 0 {
+		band--
+	} else if lat >= maxLat && band < bands-1 {
+		band++
+	}
+	return band
+}
+
+// BandBounds returns the latitudes of the southern and northern edges
+// of the band (as numbered by LatitudeBand)
+func BandBounds(band, bands int) (minLat, maxLat float64) {
+	edge := func(i int) float64 {
+		if i == bands {
+			return 90
+		}
+		return float64(i)*180/float64(bands) - 90
+	}
+	return edge(band), edge(band + 1)
+}
@@ -0,0 +1,36 @@
+package geo
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatitudeBand(t *testing.T) {
+	for _, bands := range []int{1, 2, 3, 7, 18, 180, 1000} {
+		assert.Equal(t, 0, LatitudeBand(-90, bands))
+		assert.Equal(t, bands-1, LatitudeBand(90, bands))
+
+		// no gaps between the bands
+		minLat, _ := BandBounds(0, bands)
+		assert.Equal(t, -90.0, minLat)
+		for band := 0; band < bands; band++ {
+			lo, hi := BandBounds(band, bands)
+			assert.Equal(t, minLat, lo)
+			assert.Less(t, lo, hi)
+			minLat = hi
+
+			assert.Equal(t, band, LatitudeBand(lo, bands), "%d of %d", band, bands)
+			assert.Equal(t, band, LatitudeBand((lo+hi)/2, bands), "%d of %d", band, bands)
+			assert.Equal(t, band, LatitudeBand(math.Nextafter(hi, lo), bands), "%d of %d", band, bands)
+		}
+		assert.Equal(t, 90.0, minLat)
+	}
+
+	assert.Equal(t, 1, LatitudeBand(0, 2))
+	assert.Equal(t, 0, LatitudeBand(-0.001, 2))
+	assert.Equal(t, -1, LatitudeBand(90.1, 2))
+	assert.Equal(t, -1, LatitudeBand(math.NaN(), 2))
+	assert.Equal(t, -1, LatitudeBand(0, 0))
+}
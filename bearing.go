@@ -0,0 +1,98 @@
+package geo
+
+import (
+	"math"
+)
+
+var (
+	compass8  = []string{"N", "NE", "E", "SE", "S", "SW", "W", "NW"}
+	compass16 = []string{
+		"N", "NNE", "NE", "ENE", "E", "ESE", "SE", "SSE",
+		"S", "SSW", "SW", "WSW", "W", "WNW", "NW", "NNW",
+	}
+)
+
+// Bearing returns the initial bearing (in degrees clockwise from north)
+// of the great circle path from lat1,lon1 to lat2,lon2
+func Bearing(lat1, lon1, lat2, lon2 float64) float64 {
+	dlat1 := deg2rad(lat1)
+	dlat2 := deg2rad(lat2)
+	dlon := deg2rad(lon2 - lon1)
+
+	y := math.Sin(dlon) * math.Cos(dlat2)
+	x := math.Cos(dlat1)*math.Sin(dlat2) - math.Sin(dlat1)*math.Cos(dlat2)*math.Cos(dlon)
+	return math.Mod(math.Atan2(y, x)/Radian+360, 360)
+}
+
+// MilsPerCircle is the number of (NATO) mils in a full circle
+const MilsPerCircle = 6400
+
+// DegreesToMils converts degrees to (NATO) mils
+func DegreesToMils(deg float64) float64 {
+	return deg * MilsPerCircle / 360
+}
+
+// MilsToDegrees converts (NATO) mils to degrees
+func MilsToDegrees(mils float64) float64 {
+	return mils * 360 / MilsPerCircle
+}
+
+// BearingMils is Bearing, but in mils (0..6400) rather than degrees
+func BearingMils(lat1, lon1, lat2, lon2 float64) float64 {
+	return DegreesToMils(Bearing(lat1, lon1, lat2, lon2))
+}
+
+// BearingRadians is Bearing, but in radians (0..2π) rather than degrees
+func BearingRadians(lat1, lon1, lat2, lon2 float64) float64 {
+	return deg2rad(Bearing(lat1, lon1, lat2, lon2))
+}
+
+// CompassDirection returns the compass point label (e.g., "NE" or "WSW")
+// for the bearing, using either an 8 or 16 point compass.
+// It returns an empty string for any other number of points.
+func CompassDirection(bearingDeg float64, points int) string {
+	var labels []string
+	switch points {
+	case 8:
+		labels = compass8
+	case 16:
+		labels = compass16
+	default:
+		return ""
+	}
+	step := 360.0 / float64(points)
+	bearingDeg = math.Mod(math.Mod(bearingDeg, 360)+360, 360)
+	idx := int(math.Floor(bearingDeg/step+0.5)) % points
+	return labels[idx]
+}
+
+// DirectionTo returns the 16 point compass direction from p to x
+func (p Point) DirectionTo(x Point) string {
+	return CompassDirection(p.Bearing(x), 16)
+}
+
+// Bearing returns the initial bearing from p to x
+func (p Point) Bearing(x Point) float64 {
+	return Bearing(float64(p.Lat), float64(p.Lon), float64(x.Lat), float64(x.Lon))
+}
+
+// VertexAngle returns the angle (in degrees, 0..180) at b
+// between the paths from b to a and from b to c
+func VertexAngle(a, b, c Point) float64 {
+	return math.Abs(TurnAngle(b.Bearing(c), b.Bearing(a)))
+}
+
+// TurnAngle returns the shortest turn (in degrees, -180..180)
+// from the current heading to the target bearing,
+// which is negative for a turn to the left (anticlockwise).
+// A turn to face the opposite way is always 180, never -180
+func TurnAngle(currentDeg, targetDeg float64) float64 {
+	turn := math.Mod(targetDeg-currentDeg, 360)
+	switch {
+	case turn <= -180:
+		turn += 360
+	case turn > 180:
+		turn -= 360
+	}
+	return turn
+}
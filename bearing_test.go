@@ -0,0 +1,98 @@
+package geo
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBearing(t *testing.T) {
+	assert.InDelta(t, 0.0, Bearing(0, 0, 10, 0), 1e-9)
+	assert.InDelta(t, 90.0, Bearing(0, 0, 0, 10), 1e-9)
+	assert.InDelta(t, 180.0, Bearing(10, 0, 0, 0), 1e-9)
+	assert.InDelta(t, 270.0, Bearing(0, 10, 0, 0), 1e-9)
+
+	// Destination should end up where it was pointed
+	brng := Bearing(SFLat, SFLon, ZepLat, ZepLon)
+	pt := Destination(SFLat, SFLon, Distance(SFLat, SFLon, ZepLat, ZepLon), brng)
+	assert.InDelta(t, ZepLat, float64(pt.Lat), 0.0001)
+	assert.InDelta(t, ZepLon, float64(pt.Lon), 0.0001)
+}
+
+func TestBearingUnits(t *testing.T) {
+	assert.Equal(t, 1600.0, DegreesToMils(90))
+	assert.Equal(t, 90.0, MilsToDegrees(1600))
+	assert.Equal(t, 6400.0, DegreesToMils(360))
+	assert.InDelta(t, 1600.0, BearingMils(0, 0, 0, 10), 1e-9)
+	assert.InDelta(t, math.Pi/2, BearingRadians(0, 0, 0, 10), 1e-12)
+	assert.InDelta(t, 4800.0, BearingMils(0, 10, 0, 0), 1e-9)
+	assert.InDelta(t, 3*math.Pi/2, BearingRadians(0, 10, 0, 0), 1e-12)
+}
+
+func TestCompassDirection(t *testing.T) {
+	tests := []struct {
+		bearing float64
+		points  int
+		label   string
+	}{
+		{0, 8, "N"},
+		{45, 8, "NE"},
+		{350, 8, "N"},
+		{337.4, 8, "NW"},
+		{-10, 8, "N"},
+		{720 + 90, 8, "E"},
+		{180, 8, "S"},
+		{247.5, 16, "WSW"},
+		{22.5, 16, "NNE"},
+		{355, 16, "N"},
+		{45, 4, ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.label, CompassDirection(tt.bearing, tt.points), "%f/%d", tt.bearing, tt.points)
+	}
+}
+
+func TestDirectionTo(t *testing.T) {
+	sf := GeoPoint(SFLat, SFLon)
+	assert.Equal(t, "ENE", sf.DirectionTo(GeoPoint(ZepLat, ZepLon)))
+	assert.Equal(t, "N", sf.DirectionTo(GeoPoint(PortLat, PortLon)))
+	assert.Equal(t, "ESE", sf.DirectionTo(GeoPoint(HouLat, HouLon)))
+}
+
+func TestVertexAngle(t *testing.T) {
+	b := GeoPoint(10, 10)
+	north, south := GeoPoint(11, 10), GeoPoint(9, 10)
+	east, west := GeoPoint(10, 11), GeoPoint(10, 9)
+
+	assert.InDelta(t, 90, VertexAngle(north, b, east), 0.1)
+	assert.InDelta(t, 90, VertexAngle(east, b, north), 0.1)
+	assert.InDelta(t, 90, VertexAngle(west, b, north), 0.1)
+	assert.InDelta(t, 180, VertexAngle(north, b, south), 1e-9)
+	assert.InDelta(t, 0, VertexAngle(north, b, GeoPoint(12, 10)), 1e-9)
+
+	// a parallel is not a great circle, so it bends a little
+	assert.InDelta(t, 179.83, VertexAngle(west, b, east), 0.01)
+	// but the equator is
+	assert.InDelta(t, 180, VertexAngle(GeoPoint(0, 9), GeoPoint(0, 10), GeoPoint(0, 11)), 1e-9)
+}
+
+func TestTurnAngle(t *testing.T) {
+	tests := []struct {
+		current, target, turn float64
+	}{
+		{350, 10, 20},
+		{10, 350, -20},
+		{0, 180, 180},
+		{180, 0, 180},
+		{90, 90, 0},
+		{0, 90, 90},
+		{0, 270, -90},
+		{-90, 90, 180},
+		{720, 45, 45},
+		{45, -45, -90},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.turn, TurnAngle(tt.current, tt.target), "%v -> %v", tt.current, tt.target)
+	}
+}
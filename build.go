@@ -0,0 +1,113 @@
+package geo
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Encoder is the counterpart to Decoder, for writing records
+type Encoder interface {
+	Encode([]byte) error // into a buffer of Size() bytes
+	Size() int           // size of struct
+	Point() Point
+}
+
+const (
+	headerMagic   = "geopts"
+	headerVersion = 1
+	headerSize    = 16
+)
+
+// header precedes the records in files created by BuildFile
+type header struct {
+	Version byte
+	Flags   byte   // the Layout of the records
+	Size    uint32 // of each record
+	Count   uint32 // of records
+}
+
+func (h header) encode() []byte {
+	buf := make([]byte, headerSize)
+	copy(buf, headerMagic)
+	buf[6] = h.Version
+	buf[7] = h.Flags
+	binary.LittleEndian.PutUint32(buf[8:], h.Size)
+	binary.LittleEndian.PutUint32(buf[12:], h.Count)
+	return buf
+}
+
+// readHeader returns the header of the data, if it has one
+func readHeader(b []byte) (header, bool) {
+	if len(b) < headerSize || !bytes.Equal(b[:len(headerMagic)], []byte(headerMagic)) {
+		return header{}, false
+	}
+	return header{
+		Version: b[6],
+		Flags:   b[7],
+		Size:    binary.LittleEndian.Uint32(b[8:]),
+		Count:   binary.LittleEndian.Uint32(b[12:]),
+	}, true
+}
+
+// BuildFile writes the records, sorted by their points, to a new file
+// that is ready to be searched via Mmap and NewIter.
+// All the records must be the same size.
+func BuildFile(filename string, records []Encoder) error {
+	return BuildFileLayout(filename, records, LayoutCustom)
+}
+
+// BuildFileLayout is BuildFile, but with a choice of layout.
+// With one of the standard layouts, only the coordinates
+// of the records are written, and NewLayoutIter reads them back
+func BuildFileLayout(filename string, records []Encoder, layout Layout) error {
+	// don't reorder (or convert) the caller's slice
+	sorted := make([]Encoder, len(records))
+	for i, r := range records {
+		sorted[i] = layout.record(r)
+	}
+
+	size := 0
+	if len(sorted) > 0 {
+		size = sorted[0].Size()
+	}
+	for i, r := range sorted {
+		if r.Size() != size {
+			return fmt.Errorf("record %d is %d bytes, expected %d", i, r.Size(), size)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Point().Less(sorted[j].Point())
+	})
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	hdr := header{Version: headerVersion, Flags: byte(layout), Size: uint32(size), Count: uint32(len(sorted))}
+	if _, err := w.Write(hdr.encode()); err != nil {
+		return err
+	}
+	buf := make([]byte, size)
+	for i, r := range sorted {
+		if err := r.Encode(buf); err != nil {
+			return fmt.Errorf("encoding record %d: %w", i, err)
+		}
+		if _, err := w.Write(buf); err != nil {
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,169 @@
+package geo
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildFile(t *testing.T) {
+	pts := samplePoints()
+	var records []Encoder
+	for _, pt := range shuffled(pts) {
+		records = append(records, &pointRecord{pt})
+	}
+	first := records[0]
+
+	filename := filepath.Join(t.TempDir(), "points.bin")
+	if err := BuildFile(filename, records); err != nil {
+		t.Fatal(err)
+	}
+	assert.Same(t, first, records[0], "the input should not be reordered")
+
+	m, err := Mmap(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	hdr, ok := readHeader(m.B)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(len(pts)), hdr.Count)
+	assert.Equal(t, uint32(8), hdr.Size)
+
+	iter := m.NewIter(&pointRecord{})
+	assert.Equal(t, len(pts), iter.Len())
+	assert.True(t, IsSorted(iter))
+	for _, pt := range []Point{
+		GeoPoint(AlaLat+0.051, AlaLon+0.049),
+		GeoPoint(AlaLat+0.123, AlaLon+0.177),
+		GeoPoint(AlaLat-0.001, AlaLon-0.001),
+	} {
+		want, wantDist := Bestest(pts, pt, 1.0)
+		idx, dist := Bestest(iter, pt, 1.0)
+		assert.Equal(t, want, idx)
+		assert.Equal(t, wantDist, dist)
+	}
+}
+
+type oddRecord struct {
+	pointRecord
+}
+
+func (r *oddRecord) Size() int {
+	return 12
+}
+
+func TestBuildFileMixed(t *testing.T) {
+	records := []Encoder{&pointRecord{}, &oddRecord{}}
+	err := BuildFile(filepath.Join(t.TempDir(), "points.bin"), records)
+	assert.Error(t, err)
+}
+
+func TestExternalSortBuild(t *testing.T) {
+	pts := samplePoints()
+	var records []Encoder
+	for _, pt := range shuffled(pts) {
+		records = append(records, &pointRecord{pt})
+	}
+	dir := t.TempDir()
+	want := filepath.Join(dir, "want.bin")
+	if err := BuildFile(want, records); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, limit := range []int{1, 37, len(records), 10 * len(records)} {
+		in := make(chan Encoder)
+		go func() {
+			for _, r := range records {
+				in <- r
+			}
+			close(in)
+		}()
+		filename := filepath.Join(dir, "sorted.bin")
+		if err := ExternalSortBuild(filename, in, limit); err != nil {
+			t.Fatal(err)
+		}
+		got, err := os.ReadFile(filename)
+		assert.NoError(t, err)
+		expected, err := os.ReadFile(want)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, got, "limit %d", limit)
+	}
+
+	m, err := Mmap(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	iter := m.NewIter(&pointRecord{})
+	assert.True(t, IsSorted(iter))
+	idx, _ := Bestest(iter, GeoPoint(AlaLat+0.051, AlaLon+0.049), 1.0)
+	assert.Equal(t, GeoPoint(AlaLat+0.05, AlaLon+0.05), iter.IndexPoint(idx))
+}
+
+func TestMergeRuns(t *testing.T) {
+	var records []Encoder
+	for _, pt := range shuffled(samplePoints())[:50] {
+		records = append(records, &pointRecord{pt})
+	}
+	dir := t.TempDir()
+	want := filepath.Join(dir, "want.bin")
+	if err := BuildFile(want, records); err != nil {
+		t.Fatal(err)
+	}
+	expected, err := os.ReadFile(want)
+	assert.NoError(t, err)
+
+	size := records[0].Size()
+	for _, fanIn := range []int{2, 3, 7, 50} {
+		runDir := t.TempDir()
+		var names []string
+		// a run per record, the most there can be
+		for i, r := range records {
+			name := filepath.Join(runDir, strconv.Itoa(i))
+			if err := writeRun(name, []Encoder{r}, size); err != nil {
+				t.Fatal(err)
+			}
+			names = append(names, name)
+		}
+		filename := filepath.Join(dir, "merged.bin")
+		if err := mergeRuns(filename, runDir, names, size, len(records), fanIn); err != nil {
+			t.Fatal(err)
+		}
+		got, err := os.ReadFile(filename)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, got, "fan-in %d", fanIn)
+	}
+	assert.Error(t, mergeRuns(filepath.Join(dir, "bad.bin"), dir, nil, 8, 0, 1))
+}
+
+func TestExternalSortBuildErrors(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "sorted.bin")
+	in := make(chan Encoder)
+	go func() {
+		in <- &pointRecord{}
+		in <- &oddRecord{}
+		// not blocked by the error
+		in <- &pointRecord{}
+		close(in)
+	}()
+	assert.Error(t, ExternalSortBuild(filename, in, 10))
+
+	in = make(chan Encoder)
+	close(in)
+	assert.Error(t, ExternalSortBuild(filename, in, 0))
+
+	// nothing to sort
+	in = make(chan Encoder)
+	close(in)
+	assert.NoError(t, ExternalSortBuild(filename, in, 10))
+	b, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+	hdr, ok := readHeader(b)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(0), hdr.Count)
+}
@@ -0,0 +1,70 @@
+package geo
+
+import (
+	"math"
+)
+
+// CoverageArea returns the total area (in square Km) covered by circles
+// of the given radii (in Km) around the centers, counting overlaps once.
+//
+// NOTE: this is an approximation, found by checking the center of each
+// cell in a lat/lon grid of resolutionDeg degrees, so smaller resolutions
+// are more accurate but take longer. The radii must be as many as the centers.
+// Circles reaching a pole or the antimeridian are clipped and wrapped
+// so that each cell is only counted once
+func CoverageArea(centers []Point, radiiKm []float64, resolutionDeg float64) float64 {
+	if !(resolutionDeg > 0) {
+		return 0
+	}
+	cols := int(math.Ceil(360 / resolutionDeg))
+	covered := make(map[[2]int]bool)
+	var area float64
+	for i, center := range centers {
+		radius := radiiKm[i]
+		if !center.Valid() || !(radius >= 0) {
+			continue
+		}
+		lat, lon := float64(center.Lat), float64(center.Lon)
+		deltaLat := radius / DegreeToKilometer
+		minLat, maxLat := math.Max(lat-deltaLat, -90), math.Min(lat+deltaLat, 90)
+		// the degrees of longitude needed are greatest nearest the pole
+		deltaLon := LongitudeKilometerDegrees(math.Max(math.Abs(minLat), math.Abs(maxLat)), radius)
+		lon1, span := 0, cols
+		if deltaLon < 180 {
+			lon1 = int(math.Floor((lon - deltaLon + 180) / resolutionDeg))
+			lon2 := int(math.Floor((lon + deltaLon + 180) / resolutionDeg))
+			if lon2-lon1+1 < cols {
+				span = lon2 - lon1 + 1
+			}
+		}
+		lat1 := int(math.Floor(minLat / resolutionDeg))
+		lat2 := int(math.Floor(maxLat / resolutionDeg))
+		for y := lat1; y <= lat2; y++ {
+			cellMinLat := math.Max(float64(y)*resolutionDeg, -90)
+			cellMaxLat := math.Min(float64(y+1)*resolutionDeg, 90)
+			if cellMinLat >= cellMaxLat {
+				// entirely past the pole
+				continue
+			}
+			for j := 0; j < span; j++ {
+				x := (lon1 + j) % cols
+				if x < 0 {
+					x += cols
+				}
+				cell := [2]int{y, x}
+				if covered[cell] {
+					continue
+				}
+				cellMinLon := float64(x)*resolutionDeg - 180
+				cellMaxLon := math.Min(cellMinLon+resolutionDeg, 180)
+				dist := Distance(lat, lon, (cellMinLat+cellMaxLat)/2, (cellMinLon+cellMaxLon)/2)
+				if dist > radius {
+					continue
+				}
+				covered[cell] = true
+				area += AreaInKm(cellMinLat, cellMinLon, cellMaxLat, cellMaxLon)
+			}
+		}
+	}
+	return area
+}
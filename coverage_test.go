@@ -0,0 +1,61 @@
+package geo
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoverageArea(t *testing.T) {
+	const radius = 10.0
+	a := GeoPoint(AlaLat, AlaLon)
+	b := a.OffsetMeters(0, radius*1000)
+	circle := math.Pi * radius * radius
+
+	single := CoverageArea([]Point{a}, []float64{radius}, 0.005)
+	assert.InEpsilon(t, circle, single, 0.02)
+
+	// the centers are a radius apart, so the overlapping lens is
+	// 2r²·acos(1/2) - (r/2)·√3r
+	lens := 2*radius*radius*math.Acos(0.5) - radius/2*math.Sqrt(3)*radius
+	both := CoverageArea([]Point{a, b}, []float64{radius, radius}, 0.005)
+	assert.Less(t, both, 2*single)
+	assert.InEpsilon(t, 2*circle-lens, both, 0.02)
+
+	// the same circle twice is no more coverage
+	assert.Equal(t, single, CoverageArea([]Point{a, a}, []float64{radius, radius}, 0.005))
+	assert.Zero(t, CoverageArea([]Point{a}, []float64{radius}, 0))
+
+	// invalid centers cover nothing
+	nan := GeoPoint(math.NaN(), math.NaN())
+	assert.Equal(t, single, CoverageArea([]Point{a, nan}, []float64{radius, radius}, 0.005))
+}
+
+func TestCoverageAreaPoles(t *testing.T) {
+	const radius = 5.0
+	circle := math.Pi * radius * radius
+
+	// nothing is counted past the pole
+	near := CoverageArea([]Point{GeoPoint(89.99, 0)}, []float64{radius}, 0.01)
+	assert.InEpsilon(t, circle, near, 0.05)
+	south := CoverageArea([]Point{GeoPoint(-89.99, 0)}, []float64{radius}, 0.01)
+	assert.InEpsilon(t, near, south, 0.001)
+
+	// a cap of whole rows, so it needs to be a few rows high
+	const capRadius = 50.0
+	pole := CoverageArea([]Point{GeoPoint(90, 0)}, []float64{capRadius}, 0.01)
+	assert.InEpsilon(t, math.Pi*capRadius*capRadius, pole, 0.03)
+}
+
+func TestCoverageAreaAntimeridian(t *testing.T) {
+	const radius = 10.0
+	const res = 0.01
+	inland := CoverageArea([]Point{GeoPoint(10, 170)}, []float64{radius}, res)
+	across := CoverageArea([]Point{GeoPoint(10, 180)}, []float64{radius}, res)
+	assert.InEpsilon(t, inland, across, 0.02)
+
+	// the same place either side of it is counted once
+	both := CoverageArea([]Point{GeoPoint(10, 180), GeoPoint(10, -180)}, []float64{radius, radius}, res)
+	assert.Equal(t, across, both)
+}
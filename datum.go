@@ -0,0 +1,89 @@
+package geo
+
+import (
+	"math"
+)
+
+// Datum is the geodetic reference the coordinates of a point are relative to
+type Datum int
+
+const (
+	WGS84 Datum = iota // EPSG:4326, what GPS (and everything else here) uses
+	NAD27              // North American Datum 1927 (CONUS), Clarke 1866 ellipsoid
+	ED50               // European Datum 1950, International 1924 ellipsoid
+)
+
+func (d Datum) String() string {
+	switch d {
+	case WGS84:
+		return "WGS84"
+	case NAD27:
+		return "NAD27"
+	case ED50:
+		return "ED50"
+	}
+	return "unknown"
+}
+
+// datumParams are the ellipsoid of a datum and the shift (in meters)
+// of its center to that of WGS84
+type datumParams struct {
+	a, f       float64
+	dx, dy, dz float64
+}
+
+var datums = map[Datum]datumParams{
+	WGS84: {a: wgs84A, f: wgs84F},
+	NAD27: {a: 6378206.4, f: 1 / 294.9786982, dx: -8, dy: 160, dz: 176},
+	ED50:  {a: 6378388, f: 1 / 297.0, dx: -87, dy: -98, dz: -121},
+}
+
+// Datumed is a point tagged with the datum of its coordinates
+type Datumed struct {
+	Point
+	Datum Datum
+}
+
+// Transform returns the point converted to another datum,
+// by way of WGS84 using the standard Molodensky transformation.
+//
+// The datum shifts are regional averages, good to within several meters,
+// which is about as good as a float32 coordinate anyway
+func (d Datumed) Transform(to Datum) Datumed {
+	if d.Datum == to {
+		return d
+	}
+	lat, lon := float64(d.Lat), float64(d.Lon)
+	if d.Datum != WGS84 {
+		from := datums[d.Datum]
+		lat, lon = molodensky(lat, lon, from, datums[WGS84], from.dx, from.dy, from.dz)
+	}
+	if to != WGS84 {
+		dest := datums[to]
+		lat, lon = molodensky(lat, lon, datums[WGS84], dest, -dest.dx, -dest.dy, -dest.dz)
+	}
+	return Datumed{Point: GeoPoint(lat, lon), Datum: to}
+}
+
+// molodensky shifts the coordinates (at zero height) from one ellipsoid
+// to another whose center is offset by dx,dy,dz meters
+func molodensky(lat, lon float64, from, to datumParams, dx, dy, dz float64) (float64, float64) {
+	phi, lam := deg2rad(lat), deg2rad(lon)
+	sinPhi, cosPhi := math.Sincos(phi)
+	sinLam, cosLam := math.Sincos(lam)
+
+	a, f := from.a, from.f
+	da, df := to.a-a, to.f-f
+	b := a * (1 - f)
+	e2 := f * (2 - f)
+	w := 1 - e2*sinPhi*sinPhi
+	rn := a / math.Sqrt(w)                // prime vertical radius of curvature
+	rm := a * (1 - e2) / math.Pow(w, 1.5) // meridian radius of curvature
+
+	dPhi := (-dx*sinPhi*cosLam - dy*sinPhi*sinLam + dz*cosPhi +
+		da*rn*e2*sinPhi*cosPhi/a +
+		df*(rm*a/b+rn*b/a)*sinPhi*cosPhi) / rm
+	dLam := (-dx*sinLam + dy*cosLam) / (rn * cosPhi)
+
+	return lat + dPhi/Radian, lon + dLam/Radian
+}
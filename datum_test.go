@@ -0,0 +1,32 @@
+package geo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDatumTransform(t *testing.T) {
+	nad := Datumed{Point: GeoPoint(SFLat, SFLon), Datum: NAD27}
+	wgs := nad.Transform(WGS84)
+	assert.Equal(t, WGS84, wgs.Datum)
+
+	// NAD27 is about 100m east of WGS84 in California
+	shift := nad.Distance(wgs.Point) * 1000
+	t.Logf("%v (%s) -> %v (%s): %.1fm", nad.Point, nad.Datum, wgs.Point, wgs.Datum, shift)
+	assert.InDelta(t, 100, shift, 30)
+	assert.Less(t, wgs.Lon, nad.Lon)
+
+	// and back again, to within a meter or so
+	back := wgs.Transform(NAD27)
+	assert.Equal(t, NAD27, back.Datum)
+	assert.InDelta(t, float64(nad.Lat), float64(back.Lat), 1e-5)
+	assert.InDelta(t, float64(nad.Lon), float64(back.Lon), 1e-5)
+
+	// Berlin, via WGS84
+	ed := Datumed{Point: GeoPoint(52.52, 13.405), Datum: ED50}
+	other := ed.Transform(NAD27).Transform(ED50)
+	assert.InDelta(t, float64(ed.Lat), float64(other.Lat), 1e-5)
+	assert.InDelta(t, float64(ed.Lon), float64(other.Lon), 1e-5)
+	assert.Equal(t, ed, ed.Transform(ED50))
+}
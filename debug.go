@@ -0,0 +1,15 @@
+package geo
+
+// DebugLogger receives trace output from the search routines
+// (e.g., Bestest and Closest).
+//
+// It defaults to nil (no output), set it to log.Printf (or similar)
+// to diagnose unexpected search results. It is not synchronized,
+// so set it before starting any searches.
+var DebugLogger func(format string, args ...interface{})
+
+func debugf(text string, args ...interface{}) {
+	if logger := DebugLogger; logger != nil {
+		logger(text, args...)
+	}
+}
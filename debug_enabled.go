@@ -6,6 +6,8 @@ import (
 	"log"
 )
 
-func debugf(text string, args ...interface{}) {
-	log.Printf("DBG: "+text, args...)
+func init() {
+	DebugLogger = func(text string, args ...interface{}) {
+		log.Printf("DBG: "+text, args...)
+	}
 }
@@ -1,6 +0,0 @@
-//go:build !debug
-
-package geo
-
-func debugf(_ string, _ ...interface{}) {
-}
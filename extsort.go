@@ -0,0 +1,265 @@
+package geo
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// maxMergeRuns is the most runs merged at once,
+// to stay well within the limit on open files
+const maxMergeRuns = 64
+
+// run is a sorted run of records being merged,
+// each stored as its point (8 bytes) followed by the encoded record
+type run struct {
+	r   *bufio.Reader
+	f   *os.File
+	idx int // of the run, to keep the merge stable
+	buf []byte
+	pt  Point
+}
+
+// next reads the next record, returning false at the end of the run
+func (r *run) next() (bool, error) {
+	if _, err := io.ReadFull(r.r, r.buf); err != nil {
+		if err == io.EOF {
+			return false, nil
+		}
+		return false, err
+	}
+	r.pt = DecodePoint(r.buf)
+	return true, nil
+}
+
+// runs is a min-heap of the runs by their current point
+type runs []*run
+
+func (r runs) Len() int { return len(r) }
+func (r runs) Less(i, j int) bool {
+	if r[i].pt == r[j].pt {
+		return r[i].idx < r[j].idx
+	}
+	return r[i].pt.Less(r[j].pt)
+}
+func (r runs) Swap(i, j int)       { r[i], r[j] = r[j], r[i] }
+func (r *runs) Push(x interface{}) { *r = append(*r, x.(*run)) }
+func (r *runs) Pop() interface{} {
+	old := *r
+	last := old[len(old)-1]
+	*r = old[:len(old)-1]
+	return last
+}
+
+// ExternalSortBuild is BuildFile for more records than fit in memory.
+// It reads the records from the channel, sorting them memLimit at a time
+// into temporary files, which are then merged into the final file
+// (in several passes if there are more than maxMergeRuns of them).
+//
+// If there is an error, the rest of the channel is drained
+// so the sender is not left blocked
+func ExternalSortBuild(filename string, in <-chan Encoder, memLimit int) (err error) {
+	defer func() {
+		if err != nil {
+			for range in {
+			}
+		}
+	}()
+	if memLimit < 1 {
+		return fmt.Errorf("memory limit must be at least 1 record, not %d", memLimit)
+	}
+	dir, err := os.MkdirTemp("", "geosort")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	size, count := -1, 0
+	var names []string
+	batch := make([]Encoder, 0, memLimit)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		sort.SliceStable(batch, func(i, j int) bool {
+			return batch[i].Point().Less(batch[j].Point())
+		})
+		name := filepath.Join(dir, strconv.Itoa(len(names)))
+		if err := writeRun(name, batch, size); err != nil {
+			return err
+		}
+		names = append(names, name)
+		batch = batch[:0]
+		return nil
+	}
+	for r := range in {
+		if size < 0 {
+			size = r.Size()
+		}
+		if r.Size() != size {
+			return fmt.Errorf("record %d is %d bytes, expected %d", count, r.Size(), size)
+		}
+		count++
+		if batch = append(batch, r); len(batch) == memLimit {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+	if size < 0 {
+		size = 0
+	}
+	return mergeRuns(filename, dir, names, size, count, maxMergeRuns)
+}
+
+// writeRun writes the sorted records to a temporary file
+func writeRun(name string, records []Encoder, size int) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	buf := make([]byte, 8+size)
+	for i, r := range records {
+		pt := r.Point()
+		binary.LittleEndian.PutUint32(buf, math.Float32bits(float32(pt.Lat)))
+		binary.LittleEndian.PutUint32(buf[4:], math.Float32bits(float32(pt.Lon)))
+		if err := r.Encode(buf[8:]); err != nil {
+			return fmt.Errorf("encoding record %d: %w", i, err)
+		}
+		if _, err := w.Write(buf); err != nil {
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// mergeRuns merges the sorted runs into a file in the format of BuildFile.
+// At most fanIn runs are open at once, so if there are more they are
+// first merged a group at a time into fewer, longer runs in dir
+func mergeRuns(filename, dir string, names []string, size, count, fanIn int) error {
+	if fanIn < 2 {
+		return fmt.Errorf("merge fan-in must be at least 2 runs, not %d", fanIn)
+	}
+	for pass := 0; len(names) > fanIn; pass++ {
+		var merged []string
+		for i := 0; i < len(names); i += fanIn {
+			end := i + fanIn
+			if end > len(names) {
+				end = len(names)
+			}
+			name := filepath.Join(dir, fmt.Sprintf("%d.%d", pass, len(merged)))
+			if err := mergeRunFile(name, names[i:end], size); err != nil {
+				return err
+			}
+			merged = append(merged, name)
+		}
+		// the merged runs are no longer needed, so free up the space
+		for _, name := range names {
+			os.Remove(name)
+		}
+		names = merged
+	}
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	hdr := header{Version: headerVersion, Size: uint32(size), Count: uint32(count)}
+	if _, err := w.Write(hdr.encode()); err != nil {
+		return err
+	}
+	if err := mergeTo(w, names, size, false); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// mergeRunFile merges the sorted runs into a single longer run
+func mergeRunFile(name string, names []string, size int) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	if err := mergeTo(w, names, size, true); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// mergeTo writes the records of the sorted runs to w in order,
+// with their points as well if keepPoints is set (for another run)
+func mergeTo(w io.Writer, names []string, size int, keepPoints bool) error {
+	var pending runs
+	defer func() {
+		for _, r := range pending {
+			r.f.Close()
+		}
+	}()
+	for i, name := range names {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		r := &run{r: bufio.NewReader(f), f: f, idx: i, buf: make([]byte, 8+size)}
+		pending = append(pending, r)
+		if ok, err := r.next(); err != nil {
+			return err
+		} else if !ok {
+			return fmt.Errorf("run %d is empty", i)
+		}
+	}
+	heap.Init(&pending)
+
+	skip := 8
+	if keepPoints {
+		skip = 0
+	}
+	for len(pending) > 0 {
+		r := pending[0]
+		if _, err := w.Write(r.buf[skip:]); err != nil {
+			return err
+		}
+		ok, err := r.next()
+		if err != nil {
+			return err
+		}
+		if ok {
+			heap.Fix(&pending, 0)
+			continue
+		}
+		heap.Pop(&pending)
+		r.f.Close()
+	}
+	return nil
+}
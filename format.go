@@ -0,0 +1,198 @@
+package geo
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Format is the notation used to express coordinates
+type Format int
+
+const (
+	FormatUnknown Format = iota
+	FormatDecimal        // 37.7749, -122.4194
+	FormatDMS            // 37°46'29.6"N 122°25'9.8"W
+	FormatGeohash        // 9q8yyk8yt
+	FormatUTM            // 10S 551130 4180999
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatDecimal:
+		return "decimal"
+	case FormatDMS:
+		return "DMS"
+	case FormatGeohash:
+		return "geohash"
+	case FormatUTM:
+		return "UTM"
+	}
+	return "unknown"
+}
+
+var (
+	decimalPattern = regexp.MustCompile(`^\s*([-+]?\d+(?:\.\d*)?)\s*(?:[,/]|\s)\s*([-+]?\d+(?:\.\d*)?)\s*$`)
+	geohashPattern = regexp.MustCompile(`^[0-9bcdefghjkmnpqrstuvwxyz]{1,12}$`)
+	utmPattern     = regexp.MustCompile(`^\s*(\d{1,2})\s*([C-HJ-NP-Xc-hj-np-x])\s+(\d+(?:\.\d*)?)\s*m?E?\s+(\d+(?:\.\d*)?)\s*m?N?\s*$`)
+	dmsPattern     = regexp.MustCompile(`([-+]?\d+(?:\.\d+)?)\s*°\s*(?:(\d+(?:\.\d+)?)\s*['′]\s*)?(?:(\d+(?:\.\d+)?)\s*(?:"|″|'')\s*)?([NSEWnsew])?`)
+)
+
+// DetectFormat returns which notation the coordinates are in,
+// based on the characters used.
+// It returns an error (with FormatUnknown) if it is not recognized.
+func DetectFormat(s string) (Format, error) {
+	switch {
+	case utmPattern.MatchString(s):
+		return FormatUTM, nil
+	case strings.ContainsAny(s, `°'"′″`):
+		return FormatDMS, nil
+	case decimalPattern.MatchString(s):
+		return FormatDecimal, nil
+	case geohashPattern.MatchString(strings.ToLower(strings.TrimSpace(s))):
+		return FormatGeohash, nil
+	}
+	return FormatUnknown, fmt.Errorf("unrecognized coordinates %q: %w", s, ErrInvalidCoordinates)
+}
+
+// ParseAny parses coordinates in any of the formats recognized by DetectFormat
+func ParseAny(s string) (Point, error) {
+	format, err := DetectFormat(s)
+	if err != nil {
+		return Point{}, err
+	}
+	switch format {
+	case FormatUTM:
+		return ParseUTM(s)
+	case FormatDMS:
+		return ParseDMS(s)
+	case FormatGeohash:
+		return DecodeGeohash(strings.TrimSpace(s))
+	}
+	m := decimalPattern.FindStringSubmatch(s)
+	lat, _ := strconv.ParseFloat(m[1], 64)
+	lon, _ := strconv.ParseFloat(m[2], 64)
+	if !validCoords(lat, lon) {
+		return Point{}, fmt.Errorf("coordinates out of range %q: %w", s, ErrInvalidCoordinates)
+	}
+	return GeoPoint(lat, lon), nil
+}
+
+// ParseDMS parses degrees, minutes, and seconds, e.g., 37°46'29.6"N 122°25'9.8"W.
+// The latitude is expected first, unless the hemispheres indicate otherwise.
+func ParseDMS(s string) (Point, error) {
+	matches := dmsPattern.FindAllStringSubmatch(s, -1)
+	if len(matches) != 2 {
+		return Point{}, fmt.Errorf("expected 2 coordinates in %q: %w", s, ErrInvalidCoordinates)
+	}
+	var coords [2]float64
+	var hemis [2]string
+	for i, m := range matches {
+		deg, _ := strconv.ParseFloat(m[1], 64)
+		var min, sec float64
+		if m[2] != "" {
+			min, _ = strconv.ParseFloat(m[2], 64)
+		}
+		if m[3] != "" {
+			sec, _ = strconv.ParseFloat(m[3], 64)
+		}
+		if min >= 60 || sec >= 60 {
+			return Point{}, fmt.Errorf("invalid minutes or seconds in %q: %w", m[0], ErrInvalidCoordinates)
+		}
+		value := math.Abs(deg) + min/60 + sec/3600
+		hemis[i] = strings.ToUpper(m[4])
+		if deg < 0 || hemis[i] == "S" || hemis[i] == "W" {
+			value = -value
+		}
+		coords[i] = value
+	}
+	lat, lon := coords[0], coords[1]
+	if hemis[0] == "E" || hemis[0] == "W" || hemis[1] == "N" || hemis[1] == "S" {
+		lat, lon = lon, lat
+	}
+	if !validCoords(lat, lon) {
+		return Point{}, fmt.Errorf("coordinates out of range %q: %w", s, ErrInvalidCoordinates)
+	}
+	return GeoPoint(lat, lon), nil
+}
+
+// WGS84 ellipsoid
+const (
+	wgs84A = 6378137.0
+	wgs84F = 1 / 298.257223563
+	utmK0  = 0.9996
+)
+
+// FormatDMS returns the point in degrees, minutes, and seconds
+// (to a tenth of a second), e.g., 37°46'29.6"N 122°25'9.8"W,
+// which ParseDMS will read back
+func (p Point) FormatDMS() string {
+	return dms(float64(p.Lat), "N", "S") + " " + dms(float64(p.Lon), "E", "W")
+}
+
+func dms(value float64, pos, neg string) string {
+	hemi := pos
+	if value < 0 {
+		hemi = neg
+	}
+	// round to tenths of a second first, so 59.96" carries over to the minutes
+	tenths := int64(math.Round(math.Abs(value) * 36000))
+	deg, min, sec := tenths/36000, tenths%36000/600, float64(tenths%600)/10
+	return fmt.Sprintf(`%d°%d'%.1f"%s`, deg, min, sec, hemi)
+}
+
+// ParseUTM parses a WGS84 UTM coordinate given as zone, latitude band,
+// easting and northing (in meters), e.g., "10S 551130 4180999"
+func ParseUTM(s string) (Point, error) {
+	m := utmPattern.FindStringSubmatch(s)
+	if m == nil {
+		return Point{}, fmt.Errorf("invalid UTM coordinates %q: %w", s, ErrInvalidCoordinates)
+	}
+	zone, _ := strconv.Atoi(m[1])
+	if zone < 1 || zone > 60 {
+		return Point{}, fmt.Errorf("invalid UTM zone %d: %w", zone, ErrInvalidCoordinates)
+	}
+	easting, _ := strconv.ParseFloat(m[3], 64)
+	northing, _ := strconv.ParseFloat(m[4], 64)
+	north := strings.ToUpper(m[2]) >= "N"
+	lat, lon := utmToLatLon(zone, north, easting, northing)
+	return GeoPoint(lat, lon), nil
+}
+
+// utmToLatLon is the inverse transverse mercator projection
+// (per Snyder, "Map Projections: A Working Manual")
+func utmToLatLon(zone int, north bool, easting, northing float64) (float64, float64) {
+	e2 := wgs84F * (2 - wgs84F)
+	ep2 := e2 / (1 - e2)
+	x := easting - 500000
+	y := northing
+	if !north {
+		y -= 10000000
+	}
+
+	mu := y / utmK0 / (wgs84A * (1 - e2/4 - 3*e2*e2/64 - 5*e2*e2*e2/256))
+	e1 := (1 - math.Sqrt(1-e2)) / (1 + math.Sqrt(1-e2))
+	phi := mu +
+		(3*e1/2-27*math.Pow(e1, 3)/32)*math.Sin(2*mu) +
+		(21*e1*e1/16-55*math.Pow(e1, 4)/32)*math.Sin(4*mu) +
+		(151*math.Pow(e1, 3)/96)*math.Sin(6*mu) +
+		(1097*math.Pow(e1, 4)/512)*math.Sin(8*mu)
+
+	sin, cos, tan := math.Sin(phi), math.Cos(phi), math.Tan(phi)
+	n := wgs84A / math.Sqrt(1-e2*sin*sin)
+	t := tan * tan
+	c := ep2 * cos * cos
+	r := wgs84A * (1 - e2) / math.Pow(1-e2*sin*sin, 1.5)
+	d := x / (n * utmK0)
+
+	lat := phi - (n*tan/r)*(d*d/2-
+		(5+3*t+10*c-4*c*c-9*ep2)*math.Pow(d, 4)/24+
+		(61+90*t+298*c+45*t*t-252*ep2-3*c*c)*math.Pow(d, 6)/720)
+	lon := (d - (1+2*t+c)*math.Pow(d, 3)/6 +
+		(5-2*c+28*t-3*c*c+8*ep2+24*t*t)*math.Pow(d, 5)/120) / cos
+
+	centralMeridian := float64(zone-1)*6 - 180 + 3
+	return lat / Radian, centralMeridian + lon/Radian
+}
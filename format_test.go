@@ -0,0 +1,88 @@
+package geo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeohash(t *testing.T) {
+	// the canonical example from wikipedia
+	pt := GeoPoint(42.605, -5.603)
+	assert.Equal(t, "ezs42", pt.Geohash(5))
+
+	center, err := DecodeGeohash("ezs42")
+	assert.NoError(t, err)
+	assert.InDelta(t, 42.605, float64(center.Lat), 0.03)
+	assert.InDelta(t, -5.603, float64(center.Lon), 0.03)
+
+	sf := GeoPoint(SFLat, SFLon)
+	center, err = DecodeGeohash(sf.Geohash(9))
+	assert.NoError(t, err)
+	assert.Less(t, sf.Distance(center), 0.005)
+
+	_, err = DecodeGeohash("ezs4a")
+	assert.ErrorIs(t, err, ErrInvalidCoordinates)
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		text     string
+		format   Format
+		lat, lon float64
+		within   float64 // km
+	}{
+		{"37.7749, -122.4194", FormatDecimal, 37.7749, -122.4194, 0.001},
+		{"37.7749 -122.4194", FormatDecimal, 37.7749, -122.4194, 0.001},
+		{`37°46'29.6"N 122°25'9.8"W`, FormatDMS, 37.774889, -122.419389, 0.001},
+		{`33° 52' S, 151° 12' E`, FormatDMS, -33.866667, 151.2, 0.001},
+		{"9q8yyk8yt", FormatGeohash, 37.7749, -122.4194, 0.005},
+		// on the central meridian of zone 18, at 45° north
+		{"18T 500000 4982950.4", FormatUTM, 45, -75, 0.001},
+		{"10S 551130 4180999", FormatUTM, 37.7749, -122.4194, 0.05},
+		{"56H 334369 6250948", FormatUTM, -33.8688, 151.2093, 0.05},
+	}
+	for _, tt := range tests {
+		format, err := DetectFormat(tt.text)
+		assert.NoError(t, err, tt.text)
+		assert.Equal(t, tt.format, format, tt.text)
+
+		pt, err := ParseAny(tt.text)
+		if err != nil {
+			t.Fatalf("%q failed: %v", tt.text, err)
+		}
+		dist := pt.Distance(GeoPoint(tt.lat, tt.lon))
+		assert.Less(t, dist, tt.within, "%s (%s): %v is %fkm away", tt.text, format, pt, dist)
+	}
+
+	for _, text := range []string{"", "hello, world", "37.7749", "aaaa"} {
+		format, err := DetectFormat(text)
+		assert.ErrorIs(t, err, ErrInvalidCoordinates, text)
+		assert.Equal(t, FormatUnknown, format)
+	}
+}
+
+func TestFormatDMS(t *testing.T) {
+	tests := []struct {
+		lat, lon float64
+		text     string
+	}{
+		{37.774889, -122.419389, `37°46'29.6"N 122°25'9.8"W`},
+		{-33.866667, 151.2, `33°52'0.0"S 151°12'0.0"E`},
+		{-22.9068, -43.1729, `22°54'24.5"S 43°10'22.4"W`},
+		{0, 0, `0°0'0.0"N 0°0'0.0"E`},
+		// rounds up into the next degree
+		{10.999999, -0.5, `11°0'0.0"N 0°30'0.0"W`},
+	}
+	for _, tt := range tests {
+		pt := GeoPoint(tt.lat, tt.lon)
+		text := pt.FormatDMS()
+		assert.Equal(t, tt.text, text)
+
+		back, err := ParseDMS(text)
+		assert.NoError(t, err)
+		// a tenth of a second is ~3m
+		assert.InDelta(t, tt.lat, float64(back.Lat), 0.00003, text)
+		assert.InDelta(t, tt.lon, float64(back.Lon), 0.00003, text)
+	}
+}
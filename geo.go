@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
@@ -37,6 +38,32 @@ type GeoPoints interface {
 	Len() int
 }
 
+// RawPoints adapts a plain slice of lat,lon pairs to GeoPoints
+//
+// As with any GeoPoints, the pairs must be sorted (e.g., sort.Sort)
+// before searching them
+type RawPoints [][2]float64
+
+// IndexPoint returns the point at index i
+func (r RawPoints) IndexPoint(i int) Point {
+	return GeoPoint(r[i][0], r[i][1])
+}
+
+// Len returns the number of points
+func (r RawPoints) Len() int {
+	return len(r)
+}
+
+// Less compares points as stored (i.e., by GeoType) for sorting
+func (r RawPoints) Less(i, j int) bool {
+	return r.IndexPoint(i).Less(r.IndexPoint(j))
+}
+
+// Swap swaps the pairs at i and j
+func (r RawPoints) Swap(i, j int) {
+	r[i], r[j] = r[j], r[i]
+}
+
 const (
 	// DegreeToKilometer is a "constant" for latitude but varies for longitude
 	DegreeToKilometer     = 111.111 //111.321
@@ -63,7 +90,15 @@ func deg2rad(d float64) float64 {
 //
 // Accuracy is with 1% under 80 degrees, which is good enough for most work
 func LookupLonKmPerLat(lat float64) float64 {
-	idx := int(lat * 10)
+	if math.IsNaN(lat) {
+		return lat
+	}
+	// the southern hemisphere mirrors the northern
+	idx := int(math.Abs(lat) * 10)
+	if idx >= len(lonKmLookup) {
+		// past the pole, e.g. from a box padded beyond it
+		idx = len(lonKmLookup) - 1
+	}
 	return lonKmLookup[idx]
 }
 
@@ -85,7 +120,7 @@ func LongitudeKilometers(lat, lon float64) float64 {
 
 // Expand returns the a box with a radius in kM for
 func Expand(lat, lon, radiusKM float64) Rect {
-	latx := radiusKM / lon
+	latx := radiusKM / DegreeToKilometer
 	lonx := LongitudeKilometerDegrees(lat, radiusKM)
 	return Rect{
 		Pair{lat - latx, lon - lonx},
@@ -101,8 +136,49 @@ func Distance(lat1, lon1, lat2, lon2 float64) float64 {
 	dlat2 := deg2rad(lat2)
 	dlon2 := deg2rad(lon2)
 
-	return math.Acos(math.Sin(dlat1)*math.Sin(dlat2)+math.Cos(dlat1)*math.Cos(dlat2)*math.Cos(dlon2-dlon1)) * EarthRadiusInKM
+	return math.Acos(clampUnit(math.Sin(dlat1)*math.Sin(dlat2)+math.Cos(dlat1)*math.Cos(dlat2)*math.Cos(dlon2-dlon1))) * EarthRadiusInKM
+}
+
+// clampUnit keeps a cosine within -1..1, as rounding can push it just past
+// (e.g., to 1.0000000000000002 for identical points), which acos turns into NaN
+func clampUnit(cos float64) float64 {
+	return math.Max(-1, math.Min(1, cos))
 }
+
+// DistanceAngle returns the distance (in Km) between the points,
+// along with the central angle (in radians) that it is derived from
+func DistanceAngle(a, b Point) (km, angleRad float64) {
+	lat1, lon1 := deg2rad(float64(a.Lat)), deg2rad(float64(a.Lon))
+	lat2, lon2 := deg2rad(float64(b.Lat)), deg2rad(float64(b.Lon))
+	angleRad = math.Acos(clampUnit(math.Sin(lat1)*math.Sin(lat2) + math.Cos(lat1)*math.Cos(lat2)*math.Cos(lon2-lon1)))
+	return angleRad * EarthRadiusInKM, angleRad
+}
+
+// DistancesFrom returns the distance (in Km) from origin to each of the points.
+// The trig for the origin is only done once, making it quicker than
+// calling Distance for each
+func DistancesFrom(origin Point, pts []Point) []float64 {
+	lat1, lon1 := deg2rad(float64(origin.Lat)), deg2rad(float64(origin.Lon))
+	sin1, cos1 := math.Sin(lat1), math.Cos(lat1)
+	dists := make([]float64, len(pts))
+	for i, pt := range pts {
+		lat2, lon2 := deg2rad(float64(pt.Lat)), deg2rad(float64(pt.Lon))
+		dists[i] = math.Acos(clampUnit(sin1*math.Sin(lat2)+cos1*math.Cos(lat2)*math.Cos(lon2-lon1))) * EarthRadiusInKM
+	}
+	return dists
+}
+
+// DistanceSafe is like Distance, but returns an error rather than
+// a NaN distance if any of the coordinates are NaN or Inf
+func DistanceSafe(lat1, lon1, lat2, lon2 float64) (float64, error) {
+	for _, f := range []float64{lat1, lon1, lat2, lon2} {
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return 0, fmt.Errorf("%v is not a usable coordinate: %w", f, ErrInvalidCoordinates)
+		}
+	}
+	return Distance(lat1, lon1, lat2, lon2), nil
+}
+
 func Distance32(lat1, lon1, lat2, lon2 float32) float64 {
 	return Distance(float64(lat1), float64(lon1), float64(lat2), float64(lon2))
 }
@@ -121,7 +197,7 @@ func ApproximateDistance(lat1, lon1, lat2, lon2 float64) float64 {
 	lonDegreeKm := LookupLonKmPerLat(lat2)
 	a := float64(lat2-lat1) * DegreeToKilometer
 	b := float64(lon2-lon1) * lonDegreeKm
-	return math.Sqrt(math.Pow(a, 2) + math.Pow(b, 2))
+	return math.Hypot(a, b)
 }
 
 // ApproximateDistanceGeo returns the approximate distance between 2 points
@@ -133,7 +209,55 @@ func ApproximateDistanceGeo(lat1, lon1, lat2, lon2 GeoType) float64 {
 	lonDegreeKm := LookupLonKmPerLat(float64(lat1)) //LookupLonKmPerLatInt(int(lat2))
 	a := float64(lat2-lat1) * DegreeToKilometer
 	b := float64(lon2-lon1) * lonDegreeKm
-	return math.Sqrt(math.Pow(a, 2) + math.Pow(b, 2))
+	return math.Hypot(a, b)
+}
+
+// CompareDistance reports how far off ApproximateDistanceGeo is from Distance
+// for each pair of points, to judge whether the speed is worth the
+// accuracy lost for a given dataset.
+// Pairs that can't be compared (e.g., with NaN coordinates) are skipped
+func CompareDistance(pairs [][2]Point) (maxErrKm, meanErrKm float64) {
+	var total float64
+	var count int
+	for _, pair := range pairs {
+		a, b := pair[0], pair[1]
+		diff := math.Abs(a.Distance(b) - ApproximateDistanceGeo(a.Lat, a.Lon, b.Lat, b.Lon))
+		if math.IsNaN(diff) {
+			continue
+		}
+		maxErrKm = math.Max(maxErrKm, diff)
+		total += diff
+		count++
+	}
+	if count == 0 {
+		return 0, 0
+	}
+	return maxErrKm, total / float64(count)
+}
+
+// ApproximateDistanceSq returns the square of ApproximateDistance,
+// which is cheaper to calculate and is all that's needed to compare distances
+func ApproximateDistanceSq(lat1, lon1, lat2, lon2 float64) float64 {
+	lonDegreeKm := LookupLonKmPerLat(lat2)
+	a := (lat2 - lat1) * DegreeToKilometer
+	b := (lon2 - lon1) * lonDegreeKm
+	return a*a + b*b
+}
+
+// EquirectangularDistance returns the approximate distance (in Km)
+// between 2 points, projecting them onto a plane where longitude
+// is scaled by the cosine of their mean latitude.
+//
+// Unlike ApproximateDistance, which scales by the latitude of one
+// of the points, this holds up well at high latitudes, so long as
+// the points are not too far apart (it is within 0.02% at 75° for
+// distances under 100Km), and it handles crossing the antimeridian.
+// It does not hold up for paths over (or near) the poles
+func EquirectangularDistance(a, b Point) float64 {
+	lat1, lat2 := deg2rad(float64(a.Lat)), deg2rad(float64(b.Lat))
+	x := wrapRad(deg2rad(float64(b.Lon-a.Lon))) * math.Cos((lat1+lat2)/2)
+	y := lat2 - lat1
+	return math.Hypot(x, y) * EarthRadiusInKM
 }
 
 func GeoPoint(lat, lon float64) Point {
@@ -179,6 +303,28 @@ func Coords(query string) ([]GeoType, error) {
 	return coords, nil
 }
 
+// QueryOSMBBox parses an OpenStreetMap (Overpass) bounding box,
+// which is given as "south,west,north,east". Unlike Coords,
+// any hemisphere is allowed
+func QueryOSMBBox(s string) (Rect, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return Rect{}, fmt.Errorf("expected south,west,north,east in %q: %w", s, ErrInvalidCoordinates)
+	}
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	coords, err := geos(parts...)
+	if err != nil {
+		return Rect{}, fmt.Errorf("parse failure (%v): %w", err, ErrInvalidCoordinates)
+	}
+	south, west, north, east := float64(coords[0]), float64(coords[1]), float64(coords[2]), float64(coords[3])
+	if !validCoords(south, west) || !validCoords(north, east) {
+		return Rect{}, fmt.Errorf("coordinates out of range %q: %w", s, ErrInvalidCoordinates)
+	}
+	return Rect{{south, west}, {north, east}}.Normalize(), nil
+}
+
 type Point struct {
 	Lat, Lon GeoType
 }
@@ -195,9 +341,133 @@ func (p Point) Less(x Point) bool {
 	}
 }
 
-// Label returns a consistent string representation of the coordinates
+// Label returns a consistent string representation of the coordinates,
+// e.g., "+37.77490_-122.41940"
 func (p Point) Label() string {
-	return fmt.Sprintf("%010.5f_%010.5f", p.Lat, p.Lon)
+	return p.LabelPrec(5)
+}
+
+// LabelPrec returns a fixed width string representation of the coordinates
+// with the given number of decimal places, e.g., "+37.77034_-122.25699".
+//
+// NOTE: the signs don't sort, so use SortLabel for labels that
+// need to sort in the same order as the points
+func (p Point) LabelPrec(decimals int) string {
+	if decimals < 0 {
+		decimals = 0
+	}
+	// sign and the integer digits
+	latWidth, lonWidth := 3, 4
+	if decimals > 0 {
+		latWidth += decimals + 1
+		lonWidth += decimals + 1
+	}
+	return fmt.Sprintf("%+0*.*f_%+0*.*f", latWidth, decimals, p.Lat, lonWidth, decimals, p.Lon)
+}
+
+// SortLabel is LabelPrec for labels that sort in the same order as the points.
+// The coordinates are offset by +90 and +180 so that they are never negative,
+// e.g., "127.77034_057.74301" for 37.77034,-122.25699
+func (p Point) SortLabel(decimals int) string {
+	if decimals < 0 {
+		decimals = 0
+	}
+	// the integer digits
+	width := 3
+	if decimals > 0 {
+		width += decimals + 1
+	}
+	lat, lon := float64(p.Lat)+90, float64(p.Lon)+180
+	return fmt.Sprintf("%0*.*f_%0*.*f", width, decimals, lat, width, decimals, lon)
+}
+
+// Valid returns true if the point is a usable coordinate
+func (p Point) Valid() bool {
+	return validCoords(float64(p.Lat), float64(p.Lon))
+}
+
+// Valid returns true if the pair is a usable lat,lon coordinate
+func (p Pair) Valid() bool {
+	return validCoords(p[0], p[1])
+}
+
+func validCoords(lat, lon float64) bool {
+	// NaN fails every comparison, so it is caught here as well
+	return lat >= -90 && lat <= 90 && lon >= -180 && lon <= 180
+}
+
+// RoundTo returns the point with its coordinates rounded to the given
+// number of decimal places (rounding half to even), for stable serialization
+func (p Point) RoundTo(decimals int) Point {
+	return Point{roundGeo(p.Lat, decimals), roundGeo(p.Lon, decimals)}
+}
+
+// roundGeo rounds the decimal representation of the value,
+// as the binary value of a float32 is rarely exactly on the .5 boundary
+// that it appears to be on (e.g., 37.123455)
+func roundGeo(v GeoType, decimals int) GeoType {
+	if decimals < 0 {
+		decimals = 0
+	}
+	s := strconv.FormatFloat(float64(v), 'f', -1, 32)
+	dot := strings.IndexByte(s, '.')
+	if dot < 0 || len(s)-dot-1 <= decimals {
+		return v
+	}
+	kept, rest := s[:dot+1+decimals], s[dot+1+decimals:]
+	up := rest[0] > '5' || (rest[0] == '5' && strings.TrimRight(rest[1:], "0") != "")
+	if rest[0] == '5' && !up {
+		// exactly half, so round to the even digit
+		last := kept[len(kept)-1]
+		if last == '.' {
+			last = kept[len(kept)-2]
+		}
+		up = (last-'0')%2 == 1
+	}
+	f, _ := strconv.ParseFloat(kept, 64)
+	if up {
+		// away from zero (ParseFloat keeps the sign of "-0.00")
+		f += math.Copysign(math.Pow10(-decimals), f)
+	}
+	return GeoType(f)
+}
+
+// NormalizeWest returns the point with its longitude within [-180,180)
+func (p Point) NormalizeWest() Point {
+	return Point{p.Lat, GeoType(normalizeLon(float64(p.Lon)))}
+}
+
+// NormalizeEast returns the point with its longitude within [0,360)
+func (p Point) NormalizeEast() Point {
+	return Point{p.Lat, GeoType(math.Mod(normalizeLon(float64(p.Lon))+360, 360))}
+}
+
+// OffsetMeters returns the point moved the given meters north and east
+// (negative for south and west), treating the earth as flat,
+// so it is only meant for small nudges
+func (p Point) OffsetMeters(northM, eastM float64) Point {
+	lat := float64(p.Lat) + northM/1000/DegreeToKilometer
+	lon := float64(p.Lon) + eastM/1000/LonKilos(float64(p.Lat))
+	return GeoPoint(lat, lon)
+}
+
+// Hemisphere returns 'N' or 'S', and 'E' or 'W', for the point.
+// By convention, the equator is north and the prime meridian
+// (and the antimeridian, if given as 180) is east
+func (p Point) Hemisphere() (ns, ew byte) {
+	ns, ew = 'N', 'E'
+	if p.Lat < 0 {
+		ns = 'S'
+	}
+	if p.Lon < 0 {
+		ew = 'W'
+	}
+	return ns, ew
+}
+
+// Antipode returns the point on the opposite side of the earth
+func (p Point) Antipode() Point {
+	return Point{-p.Lat, GeoType(normalizeLon(float64(p.Lon) + 180))}
 }
 
 func (p Point) Distance(x Point) float64 {
@@ -219,129 +489,35 @@ func AreaInRange64(pt Pair, distance float64) Rect {
 	return Rect{min, max}
 }
 
+// RadiusExtentDeg returns how many degrees of latitude and longitude
+// radiusKm spans at the point's latitude, i.e., the half height and
+// half width of a box around the radius. Longitude degrees shrink
+// towards the poles, so lonDeg is the larger of the two away from the equator
+func (p Point) RadiusExtentDeg(radiusKm float64) (latDeg, lonDeg float64) {
+	return radiusKm / DegreeToKilometer, LongitudeKilometerDegrees(float64(p.Lat), radiusKm)
+}
+
+// BoundingBox returns the rect that encloses a circle of radiusKm around the point
+func (p Point) BoundingBox(radiusKm float64) Rect {
+	return AreaInRange64(Pair{float64(p.Lat), float64(p.Lon)}, radiusKm)
+}
+
 // Closest searches for a matching point within the distance (in Km)
 // of the specified point.
 // It returns the index of the closest point and the distance from the target
 // If nothing is found, it returns the Len() of the points list and -1 distance
 //
 // NOTE: this is an adaptation of Bestest, but distances are approximated to
-//       minimize computational load
+//       minimize computational load. Only the distance to the point found
+//       is calculated exactly, so that is what is returned
 //
 // TODO: the len return is in line w/ Go sort.Search, but perhaps -1 would be better?
-// TODO part too: use distance func to share same routine w/ approx and haversine calcs?
 func Closest(g GeoPoints, pt Point, deltaKm float64) (int, float64) {
-	// Do a binary search to find the "closest" match
-
-	// The point found is not guaranteed to actually be
-	// the shortest distance, as it finds the first point
-	// that is equal to or *greater* than what is searched for
-
-	// It's possible for the first hit to be significantly further away,
-	// whereas an entry before it, while less than the point, is still
-	// closer.
-
-	// The final confounding factor is that the data is order by
-	// latitude *then* longitude, so the following point could be
-	// very close in latitude, the longitude could be much greater.
-	// A subsequent point could be 0.000001 degrees latitude
-	// further (0.11 m), but have the longitude diff be much less
-
-	x := sort.Search(g.Len(), func(i int) bool {
-		h := g.IndexPoint(i)
-		return pt.Less(h)
-	})
-
-	// did search fail?
-	if x == g.Len() {
-		return x, -1 //math.MaxFloat64//closest
+	idx, found, _, _ := search(g, pt, deltaKm, Point.Approximately, false)
+	if idx == g.Len() {
+		return idx, -1
 	}
-
-	// so we either came in exactly on target (not likely),
-	// or somewhat past it.
-	// Start by going backwards as we already know we probably overshot
-	// if we are greater than this then there's no possibility
-	// we will be in range continuing on
-
-	// To minimize work done (calculating distance),
-	// calculate the furthest away directly by latidude only,
-	// as that is (effectively) invariant
-	minLat := pt.Lat - GeoType(deltaKm/DegreeToKilometer)
-
-	best := x //g.Len()
-
-	//closest := deltaKm + 0.0001 // ensure we have something to best
-	counter := 0
-
-	// our first hit is guaranteed to be equal to or *greater* than our
-	// requested point.
-	//
-	// we have to check both above and below the point in question to see
-	// which has the closed hit
-	this := g.IndexPoint(x)
-	dist := this.Approximately(pt)
-	closest := dist
-	debugf("first hit for %v: %v -- %6d/%6d (%f)", pt, this, x, g.Len(), dist)
-
-	lonKmPerDegree := LookupLonKmPerLat(float64(pt.Lat)) //LookupLonKmPerLatInt(int(pt.Lat))
-	deltaLon := GeoType(closest / lonKmPerDegree)
-	lonOutside := func(lon GeoType) bool {
-		maxLon := pt.Lon + deltaLon
-		minLon := pt.Lon - deltaLon
-		return lon < minLon || lon > maxLon
-	}
-
-	// work backwards first, as we likely overshot our target
-	for i := x - 1; i > 0; i-- {
-		counter++
-		this = g.IndexPoint(i)
-		if this.Lat < minLat {
-			//debugf("%v exceeded minimum possible lat: %v", this, minLat)
-			break
-		}
-		if lonOutside(this.Lon) {
-			//debugf("below lon outside: %v", this)
-			continue
-		}
-		if dist := pt.Approximately(this); dist < closest {
-			closest = dist
-			best = i
-			minLat = pt.Lat - GeoType(closest/DegreeToKilometer)
-			deltaLon = GeoType(closest / lonKmPerDegree)
-			//debugf("(%d) MINLAT: %f", counter, minLat)
-		}
-	}
-	/*
-	   so we're within range... now we keep looking for anything closer past
-	   the initial hit, which will not last long, as we're moving *away* from our destination,
-	   and the only likely improvement is if the longitude was off and we
-	   find a hit that's closer
-
-	   The closest *possible* will be the same lon but directly above our point,
-	   so we calculate what that lat is for the current minimal distance
-	   and once we hit pass that lat we know nothing can be closer and we're
-	   done with that sweep.
-	*/
-	maxLat := pt.Lat + GeoType(closest/DegreeToKilometer)
-	for i := x + 1; i < g.Len(); i++ {
-		counter++
-		this = g.IndexPoint(i)
-		if this.Lat > maxLat {
-			//debugf("%v exceeds max lat of %v", this, maxLat)
-			break
-		}
-		if lonOutside(this.Lon) {
-			//debugf("above lon outside: %v", this)
-			continue
-		}
-		if dist := this.Approximately(pt); dist < closest {
-			best = i
-			closest = dist
-			maxLat = pt.Lat + GeoType(dist/DegreeToKilometer)
-		}
-	}
-	//debugf("Examined %d records", counter)
-
-	return best, closest
+	return idx, pt.Distance(found)
 }
 
 func between(check, min, max GeoType) bool {
@@ -352,10 +528,20 @@ func Within(lat, lon, minLat, minLon, maxLat, maxLon GeoType) bool {
 	return between(lat, minLat, maxLat) && between(lon, minLon, maxLon)
 }
 
+// WithinWrapped is like Within, but handles boxes that cross the antimeridian,
+// i.e., where minLon > maxLon (e.g., from 170 to -170)
+func WithinWrapped(lat, lon, minLat, minLon, maxLat, maxLon GeoType) bool {
+	if minLon <= maxLon {
+		return Within(lat, lon, minLat, minLon, maxLat, maxLon)
+	}
+	return between(lat, minLat, maxLat) && (lon >= minLon || lon <= maxLon)
+}
+
 func geos(ss ...string) ([]GeoType, error) {
 	ff := make([]GeoType, 0, len(ss))
 	for i, s := range ss {
-		f, err := strconv.ParseFloat(s, 64)
+		// ParseFloat already takes a leading "+"
+		f, err := strconv.ParseFloat(strings.TrimSuffix(s, "°"), 64)
 		if err != nil {
 			return nil, fmt.Errorf("(%d/%d): %q ain't a number: %w", i, len(ss), s, err)
 		}
@@ -433,6 +619,45 @@ func QueryPoint(s string) (Point, error) {
 	return Point{GeoType(pt[0]), GeoType(pt[1])}, err
 }
 
+// coordPair matches two adjacent decimal numbers, optionally comma separated
+var coordPair = regexp.MustCompile(`([-+]?\d+\.\d+)\s*,?\s*([-+]?\d+\.\d+)`)
+
+// ExtractPoint returns the first pair of decimal numbers found in the text
+// as lat,lon coordinates, ignoring any surrounding text,
+// e.g., "Store #5: 37.7749, -122.4194"
+func ExtractPoint(s string) (Point, error) {
+	m := coordPair.FindStringSubmatch(s)
+	if m == nil {
+		return Point{}, fmt.Errorf("no coordinates in %q: %w", s, ErrInvalidCoordinates)
+	}
+	// the regexp guarantees these are valid numbers
+	lat, _ := strconv.ParseFloat(m[1], 64)
+	lon, _ := strconv.ParseFloat(m[2], 64)
+	if !validCoords(lat, lon) {
+		return Point{}, fmt.Errorf("coordinates out of range %q: %w", m[0], ErrInvalidCoordinates)
+	}
+	return GeoPoint(lat, lon), nil
+}
+
+// SearchIndex returns the index of the first point after pt in the list
+// (or Len() if there is none), which is where Bestest starts searching from.
+// It can be reused as the anchor for BestestFrom
+func SearchIndex(g GeoPoints, pt Point) int {
+	return sort.Search(g.Len(), func(i int) bool {
+		return pt.Less(g.IndexPoint(i))
+	})
+}
+
+// BestestFrom is Bestest, but starts from the given anchor index
+// (see SearchIndex) rather than searching for it.
+// The results are the same whatever the anchor is, but when querying
+// a stream of nearby points, reusing the anchor from a previous query
+// saves the binary search while only examining a few more points
+func BestestFrom(g GeoPoints, pt Point, deltaKm float64, anchor int) (int, float64) {
+	idx, _, dist, _ := searchFrom(g, pt, deltaKm, Point.Distance, true, anchor)
+	return idx, dist
+}
+
 // Bestest searches for a matching point within the distance (in Km)
 // of the specified point.
 // It returns the index of the closest point and the distance from the target
@@ -442,8 +667,43 @@ func QueryPoint(s string) (Point, error) {
 //       minimize computational load
 //
 // TODO: the len return is in line w/ Go sort.Search, but perhaps -1 would be better?
-// TODO part too: use distance func to share same routine w/ approx and haversine calcs?
 func Bestest(g GeoPoints, pt Point, deltaKm float64) (int, float64) {
+	idx, dist, _ := BestestStats(g, pt, deltaKm)
+	return idx, dist
+}
+
+// BestestStats is Bestest, but also returns the number of records examined,
+// which is handy for tuning deltaKm and diagnosing slow queries
+func BestestStats(g GeoPoints, pt Point, deltaKm float64) (index int, dist float64, examined int) {
+	index, _, dist, examined = search(g, pt, deltaKm, Point.Distance, true)
+	return index, dist, examined
+}
+
+// BestestPoint is Bestest, but returns the point found (saving a lookup
+// that may have to decode a record again), and false if there is none
+func BestestPoint(g GeoPoints, pt Point, deltaKm float64) (Point, float64, bool) {
+	idx, found, dist, _ := search(g, pt, deltaKm, Point.Distance, true)
+	return found, dist, idx < g.Len()
+}
+
+// SnapTo returns the stored point nearest to p (within maxKm),
+// or p itself and false if there is none
+func (p Point) SnapTo(g GeoPoints, maxKm float64) (Point, bool) {
+	found, _, ok := BestestPoint(g, p, maxKm)
+	if !ok {
+		return p, false
+	}
+	return found, true
+}
+
+// search does the work for Bestest and Closest, using distFn
+// to compare the points (called with pt as the first argument).
+// It returns the index of the closest point, the point itself,
+// its distance per distFn, and the number of points examined.
+//
+// If bounded is false (as for Closest), the first hit is kept whatever
+// its distance, and deltaKm only limits how far the sweep goes back
+func search(g GeoPoints, pt Point, deltaKm float64, distFn func(a, b Point) float64, bounded bool) (index int, found Point, dist float64, examined int) {
 	// Do a binary search to find the "closest" match
 
 	// The point found is not guaranteed to actually be
@@ -460,14 +720,32 @@ func Bestest(g GeoPoints, pt Point, deltaKm float64) (int, float64) {
 	// A subsequent point could be 0.000001 degrees latitude
 	// further (0.11 m), but have the longitude diff be much less
 
-	x := sort.Search(g.Len(), func(i int) bool {
-		h := g.IndexPoint(i)
-		return pt.Less(h)
-	})
+	switch g.Len() {
+	case 0:
+		return 0, Point{}, -1, 0
+	case 1:
+		found = g.IndexPoint(0)
+		if dist = distFn(pt, found); dist <= deltaKm {
+			return 0, found, dist, 1
+		}
+		return 1, Point{}, -1, 1
+	}
+
+	return searchFrom(g, pt, deltaKm, distFn, bounded, SearchIndex(g, pt))
+}
 
-	// did search fail?
-	if x == g.Len() {
-		return x, -1 //math.MaxFloat64//closest
+// searchFrom is search, starting from the anchor index rather than
+// doing a binary search for it. Any anchor gives the same answer,
+// but the closer it is to pt's place in the list, the less work is done
+func searchFrom(g GeoPoints, pt Point, deltaKm float64, distFn func(a, b Point) float64, bounded bool, x int) (index int, found Point, dist float64, examined int) {
+	switch {
+	case g.Len() < 2:
+		return search(g, pt, deltaKm, distFn, bounded)
+	case x >= g.Len():
+		// past the end, so the last point is our first hit
+		x = g.Len() - 1
+	case x < 0:
+		x = 0
 	}
 
 	// so we either came in exactly on target (not likely),
@@ -492,13 +770,23 @@ func Bestest(g GeoPoints, pt Point, deltaKm float64) (int, float64) {
 	// we have to check both above and below the point in question to see
 	// which has the closed hit
 	this := g.IndexPoint(x)
-	dist := this.Distance(pt)
-	debugf("first hit: %6d/%6d (%f)", x, g.Len(), dist)
-	if dist < closest {
+	counter++
+	dist = distFn(pt, this)
+	// checked up front so the arguments aren't boxed for nothing
+	debug := DebugLogger != nil
+	if debug {
+		debugf("first hit: %6d/%6d (%f)", x, g.Len(), dist)
+	}
+	// NOTE: a NaN distance (from bad data) always fails the comparison,
+	// so those points are never selected
+	if dist < closest || (!bounded && !math.IsNaN(dist)) {
 		closest = dist
 		best = x
+		found = this
+	}
+	if debug {
+		debugf("(%d) PT.LAT:%f MINLAT:%f", counter, this.Lat, minLat)
 	}
-	debugf("(%d) PT.LAT:%f MINLAT:%f", counter, this.Lat, minLat)
 
 	// only check if lon is in range as well
 	/*
@@ -515,22 +803,27 @@ func Bestest(g GeoPoints, pt Point, deltaKm float64) (int, float64) {
 	}
 
 	// work backwards first, as we likely overshot our target
-	for i := x - 1; i > 0; i-- {
+	for i := x - 1; i >= 0; i-- {
 		counter++
 		this = g.IndexPoint(i)
 		if this.Lat < minLat {
-			debugf("%v exceeded minimum possible lat: %v", this, minLat)
+			if debug {
+				debugf("%v exceeded minimum possible lat: %v", this, minLat)
+			}
 			break
 		}
 		if lonOutside(this.Lon) {
 			continue
 		}
-		if dist := pt.Distance(this); dist < closest {
+		if dist := distFn(pt, this); dist < closest {
 			closest = dist
 			best = i
+			found = this
 			minLat = pt.Lat - GeoType(closest/DegreeToKilometer)
 			deltaLon = GeoType(closest / lonKmPerDegree)
-			debugf("(%d) MINLAT: %f", counter, minLat)
+			if debug {
+				debugf("(%d) MINLAT: %f", counter, minLat)
+			}
 		}
 	}
 	/*
@@ -549,21 +842,29 @@ func Bestest(g GeoPoints, pt Point, deltaKm float64) (int, float64) {
 		counter++
 		this = g.IndexPoint(i)
 		if this.Lat > maxLat {
-			debugf("%v exceeds max lat of %v", this, maxLat)
+			if debug {
+				debugf("%v exceeds max lat of %v", this, maxLat)
+			}
 			break
 		}
 		if lonOutside(this.Lon) {
 			continue
 		}
-		if dist := this.Distance(pt); dist < closest {
+		if dist := distFn(pt, this); dist < closest {
 			best = i
+			found = this
 			closest = dist
 			maxLat = pt.Lat + GeoType(dist/DegreeToKilometer)
 		}
 	}
-	debugf("Examined %d records", counter)
+	if debug {
+		debugf("Examined %d records", counter)
+	}
 
-	return best, closest
+	if best == g.Len() {
+		return best, Point{}, -1, counter
+	}
+	return best, found, closest, counter
 }
 
 func ToGeoType(value interface{}) (GeoType, error) {
@@ -586,13 +887,23 @@ func ToGeoType(value interface{}) (GeoType, error) {
 }
 
 func DecodePoint(buf []byte) Point {
-	Lat := GeoType(math.Float32frombits(binary.LittleEndian.Uint32(buf)))
-	Lon := GeoType(math.Float32frombits(binary.LittleEndian.Uint32(buf[4:])))
+	return DecodePointOrder(buf, binary.LittleEndian)
+}
+
+// DecodePointOrder is DecodePoint for data in the given byte order
+func DecodePointOrder(buf []byte, order binary.ByteOrder) Point {
+	Lat := GeoType(math.Float32frombits(order.Uint32(buf)))
+	Lon := GeoType(math.Float32frombits(order.Uint32(buf[4:])))
 	return Point{Lat, Lon}
 }
 
 func DecodePair(buf []byte) Pair {
-	Lat := math.Float64frombits(binary.LittleEndian.Uint64(buf))
-	Lon := math.Float64frombits(binary.LittleEndian.Uint64(buf[8:]))
+	return DecodePairOrder(buf, binary.LittleEndian)
+}
+
+// DecodePairOrder is DecodePair for data in the given byte order
+func DecodePairOrder(buf []byte, order binary.ByteOrder) Pair {
+	Lat := math.Float64frombits(order.Uint64(buf))
+	Lon := math.Float64frombits(order.Uint64(buf[8:]))
 	return Pair{Lat, Lon}
 }
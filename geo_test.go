@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"math/rand"
 	"os"
 	"path/filepath"
 	"sort"
@@ -107,6 +108,224 @@ func TestAreaInRange64(t *testing.T) {
 	assert.LessOrEqual(t, float64(diffAllowed), float64(distance-dLon))
 }
 
+func TestValid(t *testing.T) {
+	nan := math.NaN()
+	inf := math.Inf(1)
+	tests := []struct {
+		lat, lon float64
+		valid    bool
+	}{
+		{AlaLat, AlaLon, true},
+		{90, 180, true},
+		{-90, -180, true},
+		{0, 0, true},
+		{90.0001, 0, false},
+		{-90.0001, 0, false},
+		{0, 180.0001, false},
+		{0, -180.0001, false},
+		{nan, 0, false},
+		{0, nan, false},
+		{inf, 0, false},
+		{0, -inf, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.valid, Pair{tt.lat, tt.lon}.Valid(), "pair: %f,%f", tt.lat, tt.lon)
+		assert.Equal(t, tt.valid, GeoPoint(tt.lat, tt.lon).Valid(), "point: %f,%f", tt.lat, tt.lon)
+	}
+}
+
+func TestExtractPoint(t *testing.T) {
+	tests := []struct {
+		text     string
+		lat, lon float64
+	}{
+		{"Store #5: 37.7749, -122.4194", 37.7749, -122.4194},
+		{"37.7749,-122.4194", 37.7749, -122.4194},
+		{"at 37.7749 -122.4194 since 2019", 37.7749, -122.4194},
+		{"Aisle 12, shelf 3.5 -- location: +37.7749, -122.4194 (2 items)", 37.7749, -122.4194},
+		{"Sydney (pop. 5312163): -33.8688,151.2093", -33.8688, 151.2093},
+	}
+	for _, tt := range tests {
+		pt, err := ExtractPoint(tt.text)
+		if err != nil {
+			t.Fatalf("%q failed: %v", tt.text, err)
+		}
+		assert.Equal(t, GeoPoint(tt.lat, tt.lon), pt, tt.text)
+	}
+	for _, text := range []string{
+		"",
+		"Store #5",
+		"Store #5: 37, -122",
+		"only 3.5 here",
+		"way off: 137.7749, -122.4194",
+	} {
+		_, err := ExtractPoint(text)
+		assert.ErrorIs(t, err, ErrInvalidCoordinates, text)
+	}
+}
+
+func TestLabel(t *testing.T) {
+	tests := []struct {
+		lat, lon float64
+		decimals int
+		label    string
+	}{
+		{AlaLat, AlaLon, 5, "+37.77034_-122.25699"},
+		{-33.8688, 151.2093, 5, "-33.86880_+151.20930"},
+		{1.5, 2.25, 5, "+01.50000_+002.25000"},
+		{-1.5, -2.25, 2, "-01.50_-002.25"},
+		{-1.5, -2.25, 0, "-02_-002"},
+		// exact in a float32, which otherwise runs out of digits
+		{-0.5, -10.125, 7, "-00.5000000_-010.1250000"},
+		{0.0078125, 10.0078125, 7, "+00.0078125_+010.0078125"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.label, GeoPoint(tt.lat, tt.lon).LabelPrec(tt.decimals))
+	}
+	pt := GeoPoint(AlaLat, AlaLon)
+	assert.Equal(t, pt.LabelPrec(5), pt.Label())
+	assert.Equal(t, "+37.77490_-122.41940", GeoPoint(37.7749, -122.4194).Label())
+	assert.Equal(t, "-33.86880_+151.20930", GeoPoint(-33.8688, 151.2093).Label())
+	// same width regardless of sign
+	assert.Len(t, GeoPoint(-AlaLat, -AlaLon).Label(), len(GeoPoint(AlaLat, AlaLon).Label()))
+}
+
+func TestSortLabel(t *testing.T) {
+	tests := []struct {
+		lat, lon float64
+		decimals int
+		label    string
+	}{
+		{AlaLat, AlaLon, 5, "127.77034_057.74301"},
+		{-33.8688, 151.2093, 5, "056.13120_331.20930"},
+		{1.5, 2.25, 5, "091.50000_182.25000"},
+		{-1.5, -2.25, 2, "088.50_177.75"},
+		{-1.5, -2.25, 0, "088_178"},
+		{-90, -180, 1, "000.0_000.0"},
+		{90, 180, 1, "180.0_360.0"},
+		{-0.5, -10.125, 7, "089.5000000_169.8750000"},
+		{0.0078125, 10.0078125, 7, "090.0078125_190.0078125"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.label, GeoPoint(tt.lat, tt.lon).SortLabel(tt.decimals))
+	}
+
+	// labels sort the same as the points, across signs
+	points := []Point{
+		GeoPoint(-33.8688, 151.2093),
+		GeoPoint(-33.8688, -151.2093),
+		GeoPoint(-1.5, 2.25),
+		GeoPoint(-1.25, 2.25),
+		GeoPoint(0, 0),
+		GeoPoint(1.5, -2.25),
+		GeoPoint(1.5, 2.25),
+		GeoPoint(AlaLat, AlaLon),
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].Less(points[j]) })
+	labels := make([]string, len(points))
+	for i, pt := range points {
+		labels[i] = pt.SortLabel(5)
+	}
+	assert.True(t, sort.StringsAreSorted(labels), "labels out of order: %v", labels)
+}
+
+func TestRoundTo(t *testing.T) {
+	tests := []struct {
+		lat, lon         float64
+		decimals         int
+		wantLat, wantLon float64
+	}{
+		{AlaLat, AlaLon, 5, 37.77034, -122.25699},
+		{2.1234567, -2.1234567, 6, 2.123457, -2.123457},
+		// half way rounds to the even digit
+		{37.123455, -2.123465, 5, 37.12346, -2.12346},
+		{1.0000125, -1.0000135, 6, 1.000012, -1.000014},
+		{-0.000005, 0.000015, 5, -0.00000, 0.00002},
+		{-0.0000051, 0.0000049, 5, -0.00001, 0},
+	}
+	for _, tt := range tests {
+		pt := GeoPoint(tt.lat, tt.lon).RoundTo(tt.decimals)
+		assert.Equal(t, GeoPoint(tt.wantLat, tt.wantLon), pt, "%v,%v", tt.lat, tt.lon)
+	}
+	// rounding is stable
+	pt := GeoPoint(AlaLat, AlaLon).RoundTo(5)
+	assert.Equal(t, pt, pt.RoundTo(5))
+}
+
+func TestAntipode(t *testing.T) {
+	halfway := math.Pi * EarthRadiusInKM
+	for _, pt := range []Point{
+		GeoPoint(AlaLat, AlaLon),
+		GeoPoint(-33.8688, 151.2093),
+		GeoPoint(0, 0),
+		GeoPoint(45, 180),
+	} {
+		anti := pt.Antipode()
+		assert.Equal(t, -pt.Lat, anti.Lat)
+		assert.Equal(t, pt.NormalizeWest(), anti.Antipode(), "%v", pt)
+		assert.InDelta(t, halfway, pt.Distance(anti), 0.01, "%v", pt)
+	}
+	assert.Equal(t, GeoPoint(-10, -170), GeoPoint(10, 10).Antipode())
+	assert.Equal(t, GeoPoint(-10, 170), GeoPoint(10, -10).Antipode())
+}
+
+func TestOffsetMeters(t *testing.T) {
+	pt := GeoPoint(AlaLat, AlaLon)
+	north := pt.OffsetMeters(1000, 0)
+	assert.InDelta(t, 0.009, float64(north.Lat-pt.Lat), 0.0001)
+	assert.Equal(t, pt.Lon, north.Lon)
+	assert.InDelta(t, 1.0, pt.Distance(north), 0.01)
+
+	east := pt.OffsetMeters(0, 1000)
+	assert.Equal(t, pt.Lat, east.Lat)
+	assert.InDelta(t, 1.0, pt.Distance(east), 0.01)
+
+	sw := pt.OffsetMeters(-300, -400)
+	assert.InDelta(t, 0.5, pt.Distance(sw), 0.01)
+	assert.Equal(t, "SW", CompassDirection(pt.Bearing(sw), 8))
+}
+
+func TestHemisphere(t *testing.T) {
+	tests := []struct {
+		lat, lon float64
+		ns, ew   byte
+	}{
+		{AlaLat, AlaLon, 'N', 'W'},
+		{-33.8688, 151.2093, 'S', 'E'},
+		{-22.9068, -43.1729, 'S', 'W'},
+		{52.52, 13.405, 'N', 'E'},
+		{0, 0, 'N', 'E'},
+		{-0.000001, -0.000001, 'S', 'W'},
+		{90, 180, 'N', 'E'},
+		{-90, -180, 'S', 'W'},
+	}
+	for _, tt := range tests {
+		ns, ew := GeoPoint(tt.lat, tt.lon).Hemisphere()
+		assert.Equal(t, string(tt.ns)+string(tt.ew), string(ns)+string(ew), "%v,%v", tt.lat, tt.lon)
+	}
+}
+
+func TestNormalizeLon(t *testing.T) {
+	tests := []struct {
+		lon, west, east float64
+	}{
+		{190, -170, 190},
+		{-170, -170, 190},
+		{180, -180, 180},
+		{-180, -180, 180},
+		{0, 0, 0},
+		{360, 0, 0},
+		{-360, 0, 0},
+		{AlaLon, AlaLon, AlaLon + 360},
+		{540.5, -179.5, 180.5},
+	}
+	for _, tt := range tests {
+		pt := GeoPoint(AlaLat, tt.lon)
+		assert.Equal(t, GeoPoint(AlaLat, tt.west), pt.NormalizeWest(), "west: %f", tt.lon)
+		assert.Equal(t, GeoPoint(AlaLat, tt.east), pt.NormalizeEast(), "east: %f", tt.lon)
+	}
+}
+
 type testPoints []Point
 
 func (t testPoints) IndexPoint(i int) Point {
@@ -174,6 +393,20 @@ func TestBestest(t *testing.T) {
 	t.Logf("I: %d, DIST:%f TIME:%s", i, dist, time.Since(now))
 }
 
+func TestDebugLogger(t *testing.T) {
+	saved := DebugLogger
+	defer func() { DebugLogger = saved }()
+
+	var calls int
+	DebugLogger = func(format string, args ...interface{}) {
+		calls++
+		t.Logf("DBG: "+format, args...)
+	}
+	pt, list := searchSample(t, false)
+	Bestest(list, pt, 0.1)
+	assert.Greater(t, calls, 0)
+}
+
 func BenchmarkClosest(b *testing.B) {
 	pt, list := searchSample(b, false)
 	const deltaKm = 0.1
@@ -210,6 +443,32 @@ func BenchmarkApproximateDistance(b *testing.B) {
 	}
 }
 
+func TestApproximateHypot(t *testing.T) {
+	tests := []struct {
+		pt1lat, pt1lon, pt2lat, pt2lon float64
+	}{
+		{SFLat, SFLon, ZepLat, ZepLon},
+		{SFLat, SFLon, AlaLat, AlaLon},
+		{SFLat, SFLon, HouLat, HouLon},
+		{AlaLat, AlaLon, PortLat, PortLon},
+	}
+	old := func(lat1, lon1, lat2, lon2, lonDegreeKm float64) float64 {
+		a := (lat2 - lat1) * DegreeToKilometer
+		b := (lon2 - lon1) * lonDegreeKm
+		return math.Sqrt(math.Pow(a, 2) + math.Pow(b, 2))
+	}
+	for _, tt := range tests {
+		want := old(tt.pt1lat, tt.pt1lon, tt.pt2lat, tt.pt2lon, LookupLonKmPerLat(tt.pt2lat))
+		got := ApproximateDistance(tt.pt1lat, tt.pt1lon, tt.pt2lat, tt.pt2lon)
+		assert.InDelta(t, want, got, 1e-9)
+	}
+
+	// the squares of these overflow a float64
+	dist := ApproximateDistance(-1e306, 0, 10, 0)
+	assert.False(t, math.IsInf(dist, 0))
+	assert.InDelta(t, 1e306*DegreeToKilometer, dist, 1e300)
+}
+
 func TestAccuracy(t *testing.T) {
 	const (
 		pt1Lat, pt1Lon = 47.7690679, -122.2592744
@@ -297,6 +556,20 @@ func TestLonAccuracyRedux(t *testing.T) {
 	}
 }
 
+func BenchmarkSqrtPow(b *testing.B) {
+	x, y := 12.345, 67.89
+	for i := 0; i < b.N; i++ {
+		_ = math.Sqrt(math.Pow(x, 2) + math.Pow(y, 2))
+	}
+}
+
+func BenchmarkHypot(b *testing.B) {
+	x, y := 12.345, 67.89
+	for i := 0; i < b.N; i++ {
+		_ = math.Hypot(x, y)
+	}
+}
+
 func BenchmarkLonDistanceCalc(b *testing.B) {
 	const lat, lon = 37.73, -122.34
 	for i := 0; i < b.N; i++ {
@@ -423,6 +696,313 @@ func TestBestestHeat(t *testing.T) {
 	t.Logf("%d/%d:(%f) %v", idx, len(heated), dist, h)
 }
 
+func TestBestestStats(t *testing.T) {
+	heated := testHeat(t)
+	pt := GeoPoint(AlaLat, AlaLon)
+	idx, dist, tiny := BestestStats(heated, pt, 0.01)
+	t.Logf("tiny: %d/%d:(%f) examined %d", idx, len(heated), dist, tiny)
+	idx, dist, huge := BestestStats(heated, pt, 1000)
+	t.Logf("huge: %d/%d:(%f) examined %d", idx, len(heated), dist, huge)
+	assert.Less(t, tiny*10, huge)
+}
+
+func TestRawPoints(t *testing.T) {
+	pt, sample := searchSample(t, false)
+	points := sample.(testPoints)
+	raw := make(RawPoints, 0, len(points))
+	for i := len(points) - 1; i >= 0; i-- {
+		raw = append(raw, [2]float64{float64(points[i].Lat), float64(points[i].Lon)})
+	}
+	sort.Sort(raw)
+	for _, delta := range []float64{0.5, 5, 50} {
+		idx, dist := Bestest(points, pt, delta)
+		ridx, rdist := Bestest(raw, pt, delta)
+		assert.Equal(t, dist, rdist)
+		assert.Equal(t, points.IndexPoint(idx), raw.IndexPoint(ridx))
+	}
+}
+
+func TestBestestPoint(t *testing.T) {
+	heated := testHeat(t)
+	for _, pt := range []Point{
+		GeoPoint(AlaLat, AlaLon),
+		GeoPoint(SFLat, SFLon),
+		GeoPoint(AlaLat+0.123, AlaLon-0.321),
+	} {
+		idx, dist := Bestest(heated, pt, 10)
+		found, fdist, ok := BestestPoint(heated, pt, 10)
+		if !ok {
+			t.Fatalf("nothing found near %v", pt)
+		}
+		assert.Equal(t, heated.IndexPoint(idx), found)
+		assert.Equal(t, dist, fdist)
+	}
+	_, _, ok := BestestPoint(heated, GeoPoint(0, 0), 10)
+	assert.False(t, ok)
+}
+
+func TestEquirectangularDistance(t *testing.T) {
+	// Svalbard
+	start := GeoPoint(75, 15)
+	for _, brng := range []float64{0, 45, 90, 135, 200, 290} {
+		for _, km := range []float64{1, 10, 50, 100} {
+			end := Destination(75, 15, km, brng)
+			want := start.Distance(end)
+			got := EquirectangularDistance(start, end)
+			assert.InEpsilon(t, want, got, 0.0002, "%.0fkm at %.0f°", km, brng)
+			approx := start.Approximately(end)
+			t.Logf("%3.0fkm at %3.0f°: equirectangular %.4f%% approximate %.4f%%", km, brng,
+				100*math.Abs(got-want)/want, 100*math.Abs(approx-want)/want)
+		}
+	}
+	// across the antimeridian
+	west, east := GeoPoint(75, 179.9), GeoPoint(75, -179.9)
+	assert.InEpsilon(t, west.Distance(east), EquirectangularDistance(west, east), 0.001)
+}
+
+func TestCompareDistance(t *testing.T) {
+	maxErr, meanErr := CompareDistance(nil)
+	assert.Equal(t, 0.0, maxErr)
+	assert.Equal(t, 0.0, meanErr)
+
+	// due north, the approximation uses DegreeToKilometer
+	// rather than the earth's radius, so it is off by a fixed amount per degree
+	perDegree := EarthRadiusInKM*Radian - DegreeToKilometer
+	pairs := [][2]Point{
+		{GeoPoint(10, 20), GeoPoint(11, 20)},
+		{GeoPoint(10, 20), GeoPoint(13, 20)},
+	}
+	maxErr, meanErr = CompareDistance(pairs)
+	assert.InDelta(t, 3*perDegree, maxErr, 0.001)
+	assert.InDelta(t, 2*perDegree, meanErr, 0.001)
+
+	// a NaN pair is left out of both
+	nan := GeoType(math.NaN())
+	pairs = append(pairs, [2]Point{{Lat: nan, Lon: 20}, GeoPoint(10, 20)})
+	maxErr, meanErr = CompareDistance(pairs)
+	assert.InDelta(t, 3*perDegree, maxErr, 0.001)
+	assert.InDelta(t, 2*perDegree, meanErr, 0.001)
+}
+
+func TestSnapTo(t *testing.T) {
+	pts := samplePoints()
+	reading := GeoPoint(AlaLat+0.051, AlaLon+0.049)
+	snapped, ok := reading.SnapTo(pts, 1.0)
+	assert.True(t, ok)
+	assert.NotEqual(t, reading, snapped)
+	assert.Equal(t, GeoPoint(AlaLat+0.05, AlaLon+0.05), snapped)
+	assert.Contains(t, pts, snapped)
+
+	far := GeoPoint(0, 0)
+	snapped, ok = far.SnapTo(pts, 1.0)
+	assert.False(t, ok)
+	assert.Equal(t, far, snapped)
+}
+
+func TestWithinWrapped(t *testing.T) {
+	const minLat, maxLat = -10, 10
+	// across the antimeridian
+	for _, lon := range []GeoType{170, 175, 180, -180, -175, -170} {
+		assert.True(t, WithinWrapped(0, lon, minLat, 170, maxLat, -170), "%v", lon)
+		assert.False(t, Within(0, lon, minLat, 170, maxLat, -170), "%v", lon)
+	}
+	for _, lon := range []GeoType{169, -169, 0} {
+		assert.False(t, WithinWrapped(0, lon, minLat, 170, maxLat, -170), "%v", lon)
+	}
+	assert.False(t, WithinWrapped(11, 175, minLat, 170, maxLat, -170))
+
+	// otherwise it's just Within
+	assert.True(t, WithinWrapped(0, 0, minLat, -5, maxLat, 5))
+	assert.False(t, WithinWrapped(0, 175, minLat, -5, maxLat, 5))
+}
+
+func TestDistanceAngle(t *testing.T) {
+	for _, pair := range [][2]Point{
+		{GeoPoint(SFLat, SFLon), GeoPoint(ZepLat, ZepLon)},
+		{GeoPoint(AlaLat, AlaLon), GeoPoint(PortLat, PortLon)},
+		{GeoPoint(0, 0), GeoPoint(0, 90)},
+	} {
+		km, angle := DistanceAngle(pair[0], pair[1])
+		assert.Equal(t, angle*EarthRadiusInKM, km)
+		assert.Equal(t, pair[0].Distance(pair[1]), km)
+	}
+	_, angle := DistanceAngle(GeoPoint(0, 0), GeoPoint(0, 90))
+	assert.InDelta(t, math.Pi/2, angle, 1e-9)
+}
+
+func TestDistancesFrom(t *testing.T) {
+	origin := GeoPoint(AlaLat+0.051, AlaLon+0.049)
+	pts := samplePoints()
+	dists := DistancesFrom(origin, pts)
+	assert.Len(t, dists, len(pts))
+	for i, pt := range pts {
+		assert.Equal(t, origin.Distance(pt), dists[i])
+	}
+	assert.Empty(t, DistancesFrom(origin, nil))
+}
+
+func BenchmarkDistancesFrom(b *testing.B) {
+	origin := GeoPoint(AlaLat+0.051, AlaLon+0.049)
+	pts := samplePoints()
+	for i := 0; i < b.N; i++ {
+		DistancesFrom(origin, pts)
+	}
+}
+
+func BenchmarkDistancesLoop(b *testing.B) {
+	origin := GeoPoint(AlaLat+0.051, AlaLon+0.049)
+	pts := samplePoints()
+	for i := 0; i < b.N; i++ {
+		dists := make([]float64, len(pts))
+		for j, pt := range pts {
+			dists[j] = origin.Distance(pt)
+		}
+	}
+}
+
+func TestDistanceSafe(t *testing.T) {
+	dist, err := DistanceSafe(AlaLat, AlaLon, PortLat, PortLon)
+	assert.NoError(t, err)
+	assert.Equal(t, Distance(AlaLat, AlaLon, PortLat, PortLon), dist)
+
+	_, err = DistanceSafe(AlaLat, math.NaN(), PortLat, PortLon)
+	assert.ErrorIs(t, err, ErrInvalidCoordinates)
+	_, err = DistanceSafe(AlaLat, AlaLon, math.Inf(-1), PortLon)
+	assert.ErrorIs(t, err, ErrInvalidCoordinates)
+}
+
+func TestClosestDistance(t *testing.T) {
+	heated := testHeat(t)
+	for _, pt := range []Point{
+		GeoPoint(AlaLat, AlaLon),
+		GeoPoint(SFLat, SFLon),
+		GeoPoint(AlaLat+0.123, AlaLon-0.321),
+	} {
+		idx, dist := Closest(heated, pt, 10)
+		if idx == heated.Len() {
+			t.Fatalf("nothing found near %v", pt)
+		}
+		best := heated.IndexPoint(idx)
+		assert.Equal(t, pt.Distance(best), dist)
+
+		// the index is still from the approximate distances
+		approx := pt.Approximately(best)
+		for i := 0; i < heated.Len(); i++ {
+			if d := pt.Approximately(heated.IndexPoint(i)); d < approx {
+				t.Fatalf("%v: %d is closer than %d (%f vs %f)", pt, i, idx, d, approx)
+			}
+		}
+	}
+}
+
+func TestSearchSmall(t *testing.T) {
+	pt := GeoPoint(AlaLat, AlaLon)
+	near := GeoPoint(AlaLat+0.001, AlaLon)
+	below := GeoPoint(AlaLat-0.002, AlaLon)
+	far := GeoPoint(AlaLat+1, AlaLon)
+	searches := map[string]func(GeoPoints, Point, float64) (int, float64){
+		"bestest": Bestest,
+		"closest": Closest,
+	}
+	for name, search := range searches {
+		idx, dist := search(testPoints{}, pt, 1.0)
+		assert.Equal(t, 0, idx, name)
+		assert.Equal(t, -1.0, dist, name)
+
+		// a single point, on either side
+		for _, single := range []Point{near, below} {
+			idx, dist = search(testPoints{single}, pt, 1.0)
+			assert.Equal(t, 0, idx, name)
+			assert.Equal(t, pt.Distance(single), dist, name)
+		}
+		idx, dist = search(testPoints{far}, pt, 1.0)
+		assert.Equal(t, 1, idx, name)
+		assert.Equal(t, -1.0, dist, name)
+
+		// the first point is the closest
+		idx, dist = search(testPoints{below, far}, pt, 1.0)
+		assert.Equal(t, 0, idx, name)
+		assert.Equal(t, pt.Distance(below), dist, name)
+
+		// both below the point
+		idx, _ = search(testPoints{GeoPoint(AlaLat-0.01, AlaLon), below}, pt, 1.0)
+		assert.Equal(t, 1, idx, name)
+
+		idx, _ = search(testPoints{near, far}, pt, 1.0)
+		assert.Equal(t, 0, idx, name)
+	}
+}
+
+func TestSearchShared(t *testing.T) {
+	heated := testHeat(t)
+	r := rand.New(rand.NewSource(3))
+	for i := 0; i < 20; i++ {
+		pt := GeoPoint(AlaLat+r.Float64()-0.5, AlaLon+r.Float64()-0.5)
+		deltaKm := r.Float64() * 2
+
+		// Bestest is exact
+		want, wantDist := bruteForce(heated, pt, deltaKm)
+		idx, dist := Bestest(heated, pt, deltaKm)
+		if want == heated.Len() {
+			assert.Equal(t, heated.Len(), idx)
+			assert.Equal(t, -1.0, dist)
+		} else {
+			assert.Equal(t, wantDist, dist, "%v", pt)
+		}
+
+		// Closest finds the same or one a hair away,
+		// or failing that, still returns its first hit
+		idx, dist = Closest(heated, pt, deltaKm)
+		assert.Less(t, idx, heated.Len())
+		assert.Equal(t, pt.Distance(heated.IndexPoint(idx)), dist)
+		if want < heated.Len() {
+			assert.InDelta(t, wantDist, dist, 0.01, "%v", pt)
+		}
+	}
+}
+
+func TestSearchNaN(t *testing.T) {
+	pt := GeoPoint(AlaLat, AlaLon)
+	nan := GeoType(math.NaN())
+	points := testPoints{
+		{pt.Lat - 0.002, pt.Lon},
+		{pt.Lat - 0.001, pt.Lon},
+		{pt.Lat + 0.0001, nan}, // the first hit for pt
+		{pt.Lat + 0.01, pt.Lon},
+	}
+	idx, dist := Bestest(points, pt, 1.0)
+	assert.Equal(t, 1, idx)
+	assert.False(t, math.IsNaN(dist))
+
+	idx, dist = Closest(points, pt, 1.0)
+	assert.Equal(t, 1, idx)
+	assert.False(t, math.IsNaN(dist))
+}
+
+func TestSearchExactMatch(t *testing.T) {
+	// acos rounds to NaN for identical points at this latitude
+	// unless its argument is clamped
+	pt := GeoPoint(12.75, 21.675)
+	assert.Equal(t, 0.0, pt.Distance(pt))
+	km, angle := DistanceAngle(pt, pt)
+	assert.Equal(t, 0.0, km)
+	assert.Equal(t, 0.0, angle)
+	assert.Equal(t, []float64{0}, DistancesFrom(pt, []Point{pt}))
+
+	points := testPoints{
+		pt,
+		{pt.Lat, pt.Lon + 0.001},
+		{pt.Lat + 0.001, pt.Lon},
+	}
+	idx, dist := Bestest(points, pt, 1.0)
+	assert.Equal(t, 0, idx)
+	assert.Equal(t, 0.0, dist)
+
+	idx, dist = Closest(points, pt, 1.0)
+	assert.Equal(t, 0, idx)
+	assert.Equal(t, 0.0, dist)
+}
+
 func TestClosestAllocs(t *testing.T) {
 	heated := testHeat(t)
 	pt := GeoPoint(AlaLat, AlaLon)
@@ -497,3 +1077,83 @@ func loadCSV(filename string, fn func([]string) error) error {
 	}
 	return nil
 }
+
+func TestQueryOSMBBox(t *testing.T) {
+	// Berlin, as used in Overpass queries
+	box, err := QueryOSMBBox("52.3382,13.0883,52.6755,13.7611")
+	assert.Nil(t, err)
+	assert.InDelta(t, 52.3382, box[0][0], 0.00001)
+	assert.InDelta(t, 13.0883, box[0][1], 0.00001)
+	assert.InDelta(t, 52.6755, box[1][0], 0.00001)
+	assert.InDelta(t, 13.7611, box[1][1], 0.00001)
+	assert.True(t, box.ContainsPoint(Point{52.52, 13.405}))
+
+	// southern and western hemispheres are fine, as is whitespace
+	box, err = QueryOSMBBox("-34.1, -58.6, -34.5, -58.3")
+	assert.Nil(t, err)
+	assert.InDelta(t, -34.5, box[0][0], 0.00001)
+	assert.InDelta(t, -34.1, box[1][0], 0.00001)
+
+	for _, bad := range []string{"", "1,2,3", "a,b,c,d", "91,0,92,1", "0,-181,1,0"} {
+		_, err := QueryOSMBBox(bad)
+		assert.ErrorIs(t, err, ErrInvalidCoordinates, bad)
+	}
+}
+
+func TestBestestFrom(t *testing.T) {
+	heated := testHeat(t)
+	r := rand.New(rand.NewSource(5))
+	for i := 0; i < 20; i++ {
+		pt := GeoPoint(AlaLat+r.Float64()-0.5, AlaLon+r.Float64()-0.5)
+		deltaKm := r.Float64() * 2
+		want, wantDist := Bestest(heated, pt, deltaKm)
+
+		anchor := SearchIndex(heated, pt)
+		idx, dist := BestestFrom(heated, pt, deltaKm, anchor)
+		assert.Equal(t, want, idx)
+		assert.Equal(t, wantDist, dist)
+
+		// an anchor that is off (or out of range) only costs time
+		for _, off := range []int{anchor - 50, anchor + 50, -1, heated.Len()} {
+			idx, dist = BestestFrom(heated, pt, deltaKm, off)
+			assert.Equal(t, wantDist, dist, "anchor %d for %d", off, anchor)
+			if wantDist >= 0 {
+				assert.Equal(t, want, idx)
+			}
+		}
+	}
+}
+
+func TestRadiusExtentDeg(t *testing.T) {
+	latDeg, lonDeg := GeoPoint(60, 10).RadiusExtentDeg(10)
+	assert.Greater(t, lonDeg, latDeg)
+	// a degree of longitude is half as long at 60°
+	assert.InDelta(t, 2*latDeg, lonDeg, 1e-9)
+	assert.InDelta(t, 10/DegreeToKilometer, latDeg, 1e-12)
+
+	latDeg, lonDeg = GeoPoint(0, 10).RadiusExtentDeg(10)
+	assert.InDelta(t, latDeg, lonDeg, 1e-12)
+
+	box := GeoPoint(AlaLat, AlaLon).BoundingBox(10)
+	latDeg, lonDeg = GeoPoint(AlaLat, AlaLon).RadiusExtentDeg(10)
+	assert.InDelta(t, box[1][0]-box[0][0], 2*latDeg, 1e-6)
+	assert.InDelta(t, box[1][1]-box[0][1], 2*lonDeg, 1e-6)
+}
+
+func TestGeos(t *testing.T) {
+	for _, s := range []string{"37.7", "+37.7", "37.7°", "+37.7°"} {
+		ff, err := geos(s)
+		assert.NoError(t, err, s)
+		assert.Equal(t, []GeoType{37.7}, ff, s)
+	}
+	for _, s := range []string{"37.7x", "°", "37.7°°", "", "++37.7"} {
+		_, err := geos(s)
+		assert.Error(t, err, s)
+	}
+
+	coords, err := Coords("+37.7°,-122.3°,37.8,-122.2")
+	assert.NoError(t, err)
+	assert.Equal(t, []GeoType{37.7, -122.3, 37.8, -122.2}, coords)
+	_, err = Coords("37.7x,-122.3,37.8,-122.2")
+	assert.ErrorIs(t, err, ErrInvalidCoordinates)
+}
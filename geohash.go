@@ -0,0 +1,84 @@
+package geo
+
+import (
+	"fmt"
+	"strings"
+)
+
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// Geohash returns the geohash of the point with the given number of characters
+func (p Point) Geohash(precision int) string {
+	minLat, maxLat := -90.0, 90.0
+	minLon, maxLon := -180.0, 180.0
+	lat, lon := float64(p.Lat), float64(p.Lon)
+
+	var b strings.Builder
+	var bits, idx int
+	even := true // even bits are longitude
+	for b.Len() < precision {
+		if even {
+			mid := (minLon + maxLon) / 2
+			if lon >= mid {
+				idx = idx<<1 | 1
+				minLon = mid
+			} else {
+				idx <<= 1
+				maxLon = mid
+			}
+		} else {
+			mid := (minLat + maxLat) / 2
+			if lat >= mid {
+				idx = idx<<1 | 1
+				minLat = mid
+			} else {
+				idx <<= 1
+				maxLat = mid
+			}
+		}
+		even = !even
+		if bits++; bits == 5 {
+			b.WriteByte(geohashAlphabet[idx])
+			bits, idx = 0, 0
+		}
+	}
+	return b.String()
+}
+
+// geohashBounds returns the rect covered by the geohash
+func geohashBounds(hash string) (Rect, error) {
+	box := Rect{{-90, -180}, {90, 180}}
+	even := true
+	for _, c := range strings.ToLower(hash) {
+		idx := strings.IndexRune(geohashAlphabet, c)
+		if idx < 0 {
+			return Rect{}, fmt.Errorf("invalid geohash character %q: %w", c, ErrInvalidCoordinates)
+		}
+		for bit := 4; bit >= 0; bit-- {
+			axis := 0 // latitude
+			if even {
+				axis = 1
+			}
+			mid := (box[0][axis] + box[1][axis]) / 2
+			if idx&(1<<bit) != 0 {
+				box[0][axis] = mid
+			} else {
+				box[1][axis] = mid
+			}
+			even = !even
+		}
+	}
+	return box, nil
+}
+
+// DecodeGeohash returns the center of the cell for the geohash
+func DecodeGeohash(hash string) (Point, error) {
+	if hash == "" {
+		return Point{}, ErrInvalidCoordinates
+	}
+	box, err := geohashBounds(hash)
+	if err != nil {
+		return Point{}, err
+	}
+	return GeoPoint((box[0][0]+box[1][0])/2, (box[0][1]+box[1][1])/2), nil
+}
@@ -0,0 +1,281 @@
+package geo
+
+import (
+	"math"
+)
+
+// vector is a point on the unit sphere in earth-centered cartesian coordinates
+type vector [3]float64
+
+func toVector(p Point) vector {
+	lat := deg2rad(float64(p.Lat))
+	lon := deg2rad(float64(p.Lon))
+	return vector{
+		math.Cos(lat) * math.Cos(lon),
+		math.Cos(lat) * math.Sin(lon),
+		math.Sin(lat),
+	}
+}
+
+func (v vector) point() Point {
+	lat := math.Atan2(v[2], math.Hypot(v[0], v[1])) / Radian
+	lon := math.Atan2(v[1], v[0]) / Radian
+	return GeoPoint(lat, lon)
+}
+
+func (v vector) cross(x vector) vector {
+	return vector{
+		v[1]*x[2] - v[2]*x[1],
+		v[2]*x[0] - v[0]*x[2],
+		v[0]*x[1] - v[1]*x[0],
+	}
+}
+
+func (v vector) dot(x vector) float64 {
+	return v[0]*x[0] + v[1]*x[1] + v[2]*x[2]
+}
+
+func (v vector) length() float64 {
+	return math.Sqrt(v.dot(v))
+}
+
+func (v vector) unit() vector {
+	l := v.length()
+	return vector{v[0] / l, v[1] / l, v[2] / l}
+}
+
+func (v vector) negate() vector {
+	return vector{-v[0], -v[1], -v[2]}
+}
+
+// angle returns the angle in radians between the two vectors
+func (v vector) angle(x vector) float64 {
+	return math.Atan2(v.cross(x).length(), v.dot(x))
+}
+
+// onArc reports whether v lies on the minor arc from a to b
+func (v vector) onArc(a, b vector) bool {
+	const epsilon = 1e-9
+	return v.angle(a)+v.angle(b) <= a.angle(b)+epsilon
+}
+
+// Intersection returns the point where the great circle path a1->a2
+// crosses the great circle path b1->b2.
+//
+// Any two distinct great circles cross at two antipodal points,
+// so the bool is only true if one of those points lies within both
+// segments (not just on the infinite circles).
+// Paths on the same great circle do not have a single intersection
+// and return false.
+func Intersection(a1, a2, b1, b2 Point) (Point, bool) {
+	n1 := toVector(a1).cross(toVector(a2))
+	n2 := toVector(b1).cross(toVector(b2))
+	i := n1.cross(n2)
+	if i.length() < 1e-12 {
+		return Point{}, false
+	}
+	i = i.unit()
+	va1, va2 := toVector(a1), toVector(a2)
+	vb1, vb2 := toVector(b1), toVector(b2)
+	for _, v := range []vector{i, i.negate()} {
+		if v.onArc(va1, va2) && v.onArc(vb1, vb2) {
+			return v.point(), true
+		}
+	}
+	return i.point(), false
+}
+
+// EquatorCrossing returns the longitude where the great circle through
+// a and b crosses the equator heading north (the ascending node),
+// and its inclination to the equator (in degrees, 0..180,
+// with anything over 90 heading westward).
+//
+// Both values are NaN if the points are the same or antipodal,
+// as they do not define a single great circle
+func EquatorCrossing(a, b Point) (nodeLon, inclinationDeg float64) {
+	n := toVector(a).cross(toVector(b))
+	l := n.length()
+	if l < 1e-12 {
+		return math.NaN(), math.NaN()
+	}
+	// the node is perpendicular to both the pole and the normal of the circle,
+	// and of the two, this is the one where the path is heading north
+	node := vector{-n[1], n[0], 0}
+	return math.Atan2(node[1], node[0]) / Radian, math.Acos(n[2]/l) / Radian
+}
+
+// Destination returns the point reached by travelling distanceKm
+// along the great circle starting at lat,lon with the initial bearing
+// (in degrees clockwise from north)
+func Destination(lat, lon, distanceKm, bearingDeg float64) Point {
+	lat1 := deg2rad(lat)
+	lon1 := deg2rad(lon)
+	brng := deg2rad(bearingDeg)
+	delta := distanceKm / EarthRadiusInKM
+
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(delta) + math.Cos(lat1)*math.Sin(delta)*math.Cos(brng))
+	lon2 := lon1 + math.Atan2(math.Sin(brng)*math.Sin(delta)*math.Cos(lat1),
+		math.Cos(delta)-math.Sin(lat1)*math.Sin(lat2))
+
+	// keep longitude within -180..180
+	lon2 = math.Mod(lon2+3*math.Pi, 2*math.Pi) - math.Pi
+	return GeoPoint(lat2/Radian, lon2/Radian)
+}
+
+// Interpolate returns the point the fraction f of the way from a to b
+// along the great circle between them, e.g., 0.5 is the midpoint.
+// Antipodal points have no single great circle between them,
+// so a is returned for those
+func Interpolate(a, b Point, f float64) Point {
+	va, vb := toVector(a), toVector(b)
+	d := va.angle(vb)
+	sin := math.Sin(d)
+	if sin < 1e-12 {
+		return a
+	}
+	ka := math.Sin((1-f)*d) / sin
+	kb := math.Sin(f*d) / sin
+	return vector{
+		ka*va[0] + kb*vb[0],
+		ka*va[1] + kb*vb[1],
+		ka*va[2] + kb*vb[2],
+	}.point()
+}
+
+// GreatCircleWaypoints returns segments+1 evenly spaced points along
+// the great circle from a to b, including both of them, e.g., for drawing
+// the route as a curve. It returns nil if segments is less than 1
+func GreatCircleWaypoints(a, b Point, segments int) []Point {
+	if segments < 1 {
+		return nil
+	}
+	waypoints := make([]Point, segments+1)
+	waypoints[0], waypoints[segments] = a, b
+	for i := 1; i < segments; i++ {
+		waypoints[i] = Interpolate(a, b, float64(i)/float64(segments))
+	}
+	return waypoints
+}
+
+// Ring returns the points radiusKm from center at evenly spaced bearings,
+// starting due north and working clockwise.
+// The first point is repeated at the end so the ring is a closed loop.
+// It returns nil if there are fewer than 3 segments.
+func Ring(center Point, radiusKm float64, segments int) []Point {
+	if segments < 3 {
+		return nil
+	}
+	lat, lon := float64(center.Lat), float64(center.Lon)
+	ring := make([]Point, 0, segments+1)
+	step := 360.0 / float64(segments)
+	for i := 0; i < segments; i++ {
+		ring = append(ring, Destination(lat, lon, radiusKm, float64(i)*step))
+	}
+	return append(ring, ring[0])
+}
+
+// CrossTrackDistance returns the distance (in Km) of pt from the great circle
+// passing through start and end.
+// It is negative if the point is to the left of the path
+func CrossTrackDistance(start, end, pt Point) float64 {
+	d13 := start.Distance(pt) / EarthRadiusInKM
+	b13 := deg2rad(start.Bearing(pt))
+	b12 := deg2rad(start.Bearing(end))
+	return math.Asin(math.Sin(d13)*math.Sin(b13-b12)) * EarthRadiusInKM
+}
+
+// AlongTrackDistance returns the distance (in Km) from start to the point
+// on the great circle through start and end that is closest to pt.
+// It is negative if that point is behind the start
+func AlongTrackDistance(start, end, pt Point) float64 {
+	d13 := start.Distance(pt) / EarthRadiusInKM
+	b13 := deg2rad(start.Bearing(pt))
+	b12 := deg2rad(start.Bearing(end))
+	dxt := math.Asin(math.Sin(d13) * math.Sin(b13-b12))
+	dat := math.Acos(math.Min(1, math.Cos(d13)/math.Cos(dxt))) * EarthRadiusInKM
+	if math.Cos(b13-b12) < 0 {
+		return -dat
+	}
+	return dat
+}
+
+// OnSegment returns true if p is within toleranceKm of the great circle
+// segment from a to b. Beyond either end of the segment, that is the
+// distance to the nearer endpoint rather than to the (infinite) circle
+func OnSegment(a, b, p Point, toleranceKm float64) bool {
+	// the endpoints first, as the track distances from them aren't defined
+	if a.Distance(p) <= toleranceKm || b.Distance(p) <= toleranceKm {
+		return true
+	}
+	segLen := a.Distance(b)
+	if segLen == 0 {
+		return false
+	}
+	if along := AlongTrackDistance(a, b, p); along < 0 || along > segLen {
+		return false
+	}
+	return math.Abs(CrossTrackDistance(a, b, p)) <= toleranceKm
+}
+
+// PathLength returns the total distance (in Km) along the path
+func PathLength(path []Point) float64 {
+	var total float64
+	for i := 1; i < len(path); i++ {
+		total += path[i-1].Distance(path[i])
+	}
+	return total
+}
+
+// NearestOnPath returns the point on the path (which is not necessarily a vertex)
+// that is closest to pt, the index of the segment it is on
+// (i.e., between path[segIdx] and path[segIdx+1]),
+// the distance from pt to it, and the distance along the path to reach it.
+//
+// If the path has less than 2 points, segIdx is -1
+func NearestOnPath(path []Point, pt Point) (proj Point, segIdx int, distToLineKm, distAlongKm float64) {
+	segIdx = -1
+	var travelled float64
+	for i := 1; i < len(path); i++ {
+		start, end := path[i-1], path[i]
+		segLen := start.Distance(end)
+		var along float64
+		if segLen > 0 {
+			along = math.Max(0, math.Min(segLen, AlongTrackDistance(start, end, pt)))
+		}
+		var here Point
+		switch along {
+		case 0:
+			here = start
+		case segLen:
+			here = end
+		default:
+			here = Destination(float64(start.Lat), float64(start.Lon), along, start.Bearing(end))
+		}
+		if dist := pt.Distance(here); segIdx < 0 || dist < distToLineKm {
+			proj, segIdx, distToLineKm, distAlongKm = here, i-1, dist, travelled+along
+		}
+		travelled += segLen
+	}
+	return proj, segIdx, distToLineKm, distAlongKm
+}
+
+// MoveTowards returns the point stepKm along the great circle from p
+// towards target, or the target itself if it is no further than that
+func (p Point) MoveTowards(target Point, stepKm float64) Point {
+	if p.Distance(target) <= stepKm {
+		return target
+	}
+	return Destination(float64(p.Lat), float64(p.Lon), stepKm, p.Bearing(target))
+}
+
+// RouteProgress returns how far along the route (0..1) the point nearest
+// to current is, e.g., for a progress bar. It returns 0 for a route
+// with no length
+func RouteProgress(route []Point, current Point) float64 {
+	total := PathLength(route)
+	if !(total > 0) {
+		return 0
+	}
+	_, _, _, along := NearestOnPath(route, current)
+	return math.Max(0, math.Min(1, along/total))
+}
@@ -0,0 +1,255 @@
+package geo
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIntersection(t *testing.T) {
+	// an X centered on (10, 10)
+	a1, a2 := GeoPoint(5, 5), GeoPoint(15, 15)
+	b1, b2 := GeoPoint(15, 5), GeoPoint(5, 15)
+	pt, ok := Intersection(a1, a2, b1, b2)
+	if !ok {
+		t.Fatal("crossing paths should intersect")
+	}
+	// great circles bow towards the pole, so the crossing is a bit north of 10
+	assert.InDelta(t, 10.0, float64(pt.Lon), 0.001)
+	assert.InDelta(t, 10.0, float64(pt.Lat), 0.2)
+	t.Logf("intersection: %v", pt)
+}
+
+func TestIntersectionParallel(t *testing.T) {
+	// two short paths heading due east
+	a1, a2 := GeoPoint(AlaLat, AlaLon), GeoPoint(AlaLat, AlaLon+0.1)
+	b1, b2 := GeoPoint(AlaLat+0.1, AlaLon), GeoPoint(AlaLat+0.1, AlaLon+0.1)
+	_, ok := Intersection(a1, a2, b1, b2)
+	assert.False(t, ok)
+
+	// same great circle
+	_, ok = Intersection(GeoPoint(0, 0), GeoPoint(0, 10), GeoPoint(0, 5), GeoPoint(0, 20))
+	assert.False(t, ok)
+}
+
+func TestIntersectionOutside(t *testing.T) {
+	// the circles cross at (0, 10), which is beyond the end of the second path
+	a1, a2 := GeoPoint(0, 0), GeoPoint(0, 20)
+	b1, b2 := GeoPoint(5, 10), GeoPoint(10, 10)
+	_, ok := Intersection(a1, a2, b1, b2)
+	assert.False(t, ok)
+
+	// extend it and it hits
+	pt, ok := Intersection(a1, a2, GeoPoint(-5, 10), b2)
+	assert.True(t, ok)
+	assert.InDelta(t, 0.0, float64(pt.Lat), 0.0001)
+	assert.InDelta(t, 10.0, float64(pt.Lon), 0.0001)
+}
+
+func TestDestination(t *testing.T) {
+	pt := Destination(SFLat, SFLon, SFtoZep, 0)
+	assert.InDelta(t, SFtoZep, Distance(SFLat, SFLon, float64(pt.Lat), float64(pt.Lon)), 0.01)
+	assert.InDelta(t, SFLon, float64(pt.Lon), 0.0001)
+
+	// wraps across the antimeridian
+	pt = Destination(0, 179.5, 111.2, 90)
+	assert.Less(t, float64(pt.Lon), -179.0)
+}
+
+func TestRing(t *testing.T) {
+	const radiusKm = 5.0
+	center := GeoPoint(AlaLat, AlaLon)
+	ring := Ring(center, radiusKm, 36)
+	assert.Len(t, ring, 37)
+	assert.Equal(t, ring[0], ring[len(ring)-1])
+	for _, pt := range ring {
+		// float32 coordinates give us ~meter accuracy
+		assert.InDelta(t, radiusKm, center.Distance(pt), 0.005)
+	}
+	assert.Nil(t, Ring(center, radiusKm, 2))
+}
+
+func TestCrossTrack(t *testing.T) {
+	// along the equator, a point 1 degree north of it
+	start, end := GeoPoint(0, 0), GeoPoint(0, 10)
+	oneDegree := EarthRadiusInKM * Radian
+	assert.InDelta(t, -oneDegree, CrossTrackDistance(start, end, GeoPoint(1, 5)), 0.01)
+	assert.InDelta(t, oneDegree, CrossTrackDistance(start, end, GeoPoint(-1, 5)), 0.01)
+	assert.InDelta(t, 5*oneDegree, AlongTrackDistance(start, end, GeoPoint(1, 5)), 0.01)
+	assert.InDelta(t, -2*oneDegree, AlongTrackDistance(start, end, GeoPoint(1, -2)), 0.01)
+}
+
+func TestOnSegment(t *testing.T) {
+	a, b := GeoPoint(0, 0), GeoPoint(0, 10)
+	oneDegree := EarthRadiusInKM * Radian
+
+	assert.True(t, OnSegment(a, b, GeoPoint(0, 5), 0.1))
+	assert.True(t, OnSegment(a, b, GeoPoint(0.1, 5), 0.2*oneDegree))
+	assert.True(t, OnSegment(a, b, a, 0.1))
+	assert.True(t, OnSegment(a, b, b, 0.1))
+
+	// off to the side of the segment
+	assert.False(t, OnSegment(a, b, GeoPoint(1, 5), 10))
+
+	// on the great circle, but past the end of the segment
+	assert.False(t, OnSegment(a, b, GeoPoint(0, 11), 10))
+	assert.False(t, OnSegment(a, b, GeoPoint(0, -1), 10))
+	// but close enough to the end
+	assert.True(t, OnSegment(a, b, GeoPoint(0, 10.05), 10))
+
+	// at the endpoints, where the track distances are undefined
+	c, d := GeoPoint(12.75, 21.675), GeoPoint(13, 22)
+	assert.True(t, OnSegment(c, d, c, 0.001))
+	assert.True(t, OnSegment(c, d, d, 0.001))
+	assert.True(t, OnSegment(c, d, c, 0))
+
+	// a segment of one point is just that point
+	assert.True(t, OnSegment(a, a, GeoPoint(0.01, 0), 2))
+	assert.False(t, OnSegment(a, a, GeoPoint(1, 0), 2))
+}
+
+func TestNearestOnPath(t *testing.T) {
+	path := []Point{GeoPoint(0, 0), GeoPoint(0, 1), GeoPoint(1, 1), GeoPoint(1, 2)}
+	oneDegree := EarthRadiusInKM * Radian
+	assert.InDelta(t, 3*oneDegree, PathLength(path), 0.1)
+
+	// just east of the middle of the second (northbound) segment
+	proj, seg, dist, along := NearestOnPath(path, GeoPoint(0.5, 1.01))
+	assert.Equal(t, 1, seg)
+	assert.InDelta(t, 0.5, float64(proj.Lat), 0.0001)
+	assert.InDelta(t, 1.0, float64(proj.Lon), 0.0001)
+	assert.InDelta(t, 0.01*oneDegree, dist, 0.01)
+	assert.InDelta(t, 1.5*oneDegree, along, 0.1)
+
+	// past the end of the path
+	proj, seg, _, along = NearestOnPath(path, GeoPoint(1, 3))
+	assert.Equal(t, 2, seg)
+	assert.Equal(t, path[3], proj)
+	assert.InDelta(t, PathLength(path), along, 1e-9)
+
+	_, seg, _, _ = NearestOnPath(path[:1], GeoPoint(1, 3))
+	assert.Equal(t, -1, seg)
+
+	// right on a vertex, e.g., a GPS fix at a waypoint
+	route := []Point{GeoPoint(12.7, 21.6), GeoPoint(12.75, 21.675), GeoPoint(12.8, 21.7)}
+	proj, seg, dist, along = NearestOnPath(route, route[1])
+	assert.Equal(t, route[1], proj)
+	assert.Equal(t, 0, seg)
+	assert.Equal(t, 0.0, dist)
+	assert.InDelta(t, route[0].Distance(route[1]), along, 1e-9)
+	proj, _, dist, along = NearestOnPath(route, route[0])
+	assert.Equal(t, route[0], proj)
+	assert.Equal(t, 0.0, dist)
+	assert.Equal(t, 0.0, along)
+}
+
+func TestEquatorCrossing(t *testing.T) {
+	a, b := GeoPoint(SFLat, SFLon), GeoPoint(ZepLat, ZepLon)
+	nodeLon, incl := EquatorCrossing(a, b)
+	t.Logf("node: %f inclination: %f", nodeLon, incl)
+	// heading north east, so the node is to the west, within a quarter turn
+	assert.Less(t, nodeLon, SFLon)
+	assert.Greater(t, nodeLon, SFLon-90)
+
+	// the node is on the path
+	node := GeoPoint(0, nodeLon)
+	assert.InDelta(t, 0, CrossTrackDistance(a, b, node), 0.01)
+	// it crosses going north
+	assert.Less(t, node.Distance(a), node.Distance(b))
+
+	// and it tops out at the inclination (Clairaut's relation)
+	brng := deg2rad(a.Bearing(b))
+	maxLat := math.Acos(math.Abs(math.Sin(brng)*math.Cos(deg2rad(SFLat)))) / Radian
+	assert.InDelta(t, maxLat, incl, 0.001)
+
+	// heading back the other way is a retrograde orbit through the opposite node
+	back, retro := EquatorCrossing(b, a)
+	assert.InDelta(t, 180-incl, retro, 0.001)
+	assert.InDelta(t, nodeLon+180, back, 0.001)
+
+	nodeLon, incl = EquatorCrossing(a, a)
+	assert.True(t, math.IsNaN(nodeLon))
+	assert.True(t, math.IsNaN(incl))
+}
+
+func TestMoveTowards(t *testing.T) {
+	start := GeoPoint(SFLat, SFLon)
+	target := GeoPoint(ZepLat, ZepLon)
+	total := start.Distance(target)
+
+	pt := start
+	remaining := total
+	for i := 0; pt != target; i++ {
+		if !assert.Less(t, i, 100, "never reached the target") {
+			break
+		}
+		pt = pt.MoveTowards(target, 5)
+		left := pt.Distance(target)
+		if pt != target {
+			assert.InDelta(t, remaining-5, left, 0.01)
+		}
+		assert.LessOrEqual(t, start.Distance(pt), total+0.01)
+		remaining = left
+	}
+	assert.Equal(t, target, pt)
+	assert.Equal(t, target, target.MoveTowards(target, 1))
+}
+
+func TestInterpolate(t *testing.T) {
+	a, b := GeoPoint(SFLat, SFLon), GeoPoint(ZepLat, ZepLon)
+	mid := Interpolate(a, b, 0.5)
+	assert.InDelta(t, a.Distance(mid), mid.Distance(b), 0.01)
+	assert.InDelta(t, a.Distance(b)/2, a.Distance(mid), 0.01)
+	assert.InDelta(t, float64(a.Lat), float64(Interpolate(a, b, 0).Lat), 0.00001)
+	assert.InDelta(t, float64(b.Lon), float64(Interpolate(a, b, 1).Lon), 0.00001)
+
+	// along the equator
+	pt := Interpolate(GeoPoint(0, 10), GeoPoint(0, 20), 0.25)
+	assert.InDelta(t, 0, float64(pt.Lat), 0.00001)
+	assert.InDelta(t, 12.5, float64(pt.Lon), 0.00001)
+}
+
+func TestGreatCircleWaypoints(t *testing.T) {
+	a, b := GeoPoint(SFLat, SFLon), GeoPoint(51.5074, -0.1278) // London
+	const segments = 10
+	waypoints := GreatCircleWaypoints(a, b, segments)
+	assert.Len(t, waypoints, segments+1)
+	assert.Equal(t, a, waypoints[0])
+	assert.Equal(t, b, waypoints[segments])
+
+	step := a.Distance(b) / segments
+	for i := 1; i < len(waypoints); i++ {
+		assert.InDelta(t, step, waypoints[i-1].Distance(waypoints[i]), 0.1)
+		assert.InDelta(t, 0, CrossTrackDistance(a, b, waypoints[i]), 0.01)
+	}
+	// the route heads well north of both ends
+	assert.Greater(t, float64(waypoints[segments/2].Lat), 60.0)
+
+	assert.Nil(t, GreatCircleWaypoints(a, b, 0))
+}
+
+func TestRouteProgress(t *testing.T) {
+	// three legs of one degree each
+	route := []Point{GeoPoint(0, 0), GeoPoint(0, 1), GeoPoint(1, 1), GeoPoint(1, 2)}
+
+	// halfway up the second leg, just off to the side
+	assert.InDelta(t, 0.5, RouteProgress(route, GeoPoint(0.5, 1.01)), 0.001)
+	assert.InDelta(t, 0.5/3, RouteProgress(route, GeoPoint(0.01, 0.5)), 0.001)
+	assert.InDelta(t, 2.75/3, RouteProgress(route, GeoPoint(1, 1.75)), 0.001)
+
+	// before the start and past the end
+	assert.Equal(t, 0.0, RouteProgress(route, GeoPoint(0, -1)))
+	assert.InDelta(t, 1, RouteProgress(route, GeoPoint(1, 3)), 1e-9)
+
+	// right on the vertices
+	for i, want := range []float64{0, 1.0 / 3, 2.0 / 3, 1} {
+		assert.InDelta(t, want, RouteProgress(route, route[i]), 0.001, "vertex %d", i)
+	}
+	turn := []Point{GeoPoint(12.7, 21.6), GeoPoint(12.75, 21.675), GeoPoint(12.8, 21.6)}
+	progress := RouteProgress(turn, turn[1])
+	assert.InDelta(t, turn[0].Distance(turn[1])/PathLength(turn), progress, 1e-9)
+
+	assert.Equal(t, 0.0, RouteProgress(route[:1], GeoPoint(0, 0)))
+	assert.Equal(t, 0.0, RouteProgress(nil, GeoPoint(0, 0)))
+}
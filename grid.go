@@ -0,0 +1,152 @@
+package geo
+
+import (
+	"fmt"
+	"math"
+	"sync"
+)
+
+type gridCell [2]int
+
+type gridEntry struct {
+	id int
+	pt Point
+}
+
+// Grid is a spatial index that buckets points into cells
+// of a fixed size (in degrees), for data that does not stay put
+// long enough to keep sorted.
+//
+// It is safe for concurrent use.
+type Grid struct {
+	mu      sync.RWMutex
+	cellDeg float64
+	cols    int // around the world
+	cells   map[gridCell][]gridEntry
+	ids     map[int]gridCell
+}
+
+// NewGrid returns an empty Grid with cells of cellDeg degrees on a side.
+// It panics if cellDeg is not positive
+func NewGrid(cellDeg float64) *Grid {
+	if !(cellDeg > 0) {
+		panic(fmt.Sprintf("grid cell size must be positive, not %v", cellDeg))
+	}
+	return &Grid{
+		cellDeg: cellDeg,
+		cols:    int(math.Ceil(360 / cellDeg)),
+		cells:   make(map[gridCell][]gridEntry),
+		ids:     make(map[int]gridCell),
+	}
+}
+
+func (g *Grid) cell(lat, lon float64) gridCell {
+	return gridCell{g.row(lat), g.column(lon)}
+}
+
+func (g *Grid) row(lat float64) int {
+	return int(math.Floor(lat / g.cellDeg))
+}
+
+// column wraps around the antimeridian, so lon -180 and 180 are neighbors
+func (g *Grid) column(lon float64) int {
+	x := int(math.Floor((lon+180)/g.cellDeg)) % g.cols
+	if x < 0 {
+		x += g.cols
+	}
+	return x
+}
+
+// Len returns the number of points in the grid
+func (g *Grid) Len() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return len(g.ids)
+}
+
+// Insert adds the point to the grid, replacing any point
+// previously inserted with the same id
+func (g *Grid) Insert(id int, pt Point) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.remove(id)
+	c := g.cell(float64(pt.Lat), float64(pt.Lon))
+	g.cells[c] = append(g.cells[c], gridEntry{id, pt})
+	g.ids[id] = c
+}
+
+// Remove deletes the point with the given id,
+// returning false if there was no such point
+func (g *Grid) Remove(id int) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.remove(id)
+}
+
+func (g *Grid) remove(id int) bool {
+	c, ok := g.ids[id]
+	if !ok {
+		return false
+	}
+	delete(g.ids, id)
+	entries := g.cells[c]
+	for i, e := range entries {
+		if e.id == id {
+			last := len(entries) - 1
+			entries[i] = entries[last]
+			entries = entries[:last]
+			break
+		}
+	}
+	if len(entries) == 0 {
+		delete(g.cells, c)
+	} else {
+		g.cells[c] = entries
+	}
+	return true
+}
+
+// Nearest returns the id of the closest point within maxKm of pt,
+// and its distance. If nothing is found, it returns -1 and -1 distance.
+// Points the same distance away are broken by the lowest id,
+// so the result doesn't depend on the order they were inserted.
+// The search wraps around the antimeridian, and near a pole
+// covers every longitude
+func (g *Grid) Nearest(pt Point, maxKm float64) (int, float64) {
+	if !pt.Valid() || !(maxKm >= 0) {
+		return -1, -1
+	}
+	lat, lon := float64(pt.Lat), float64(pt.Lon)
+	deltaLat := maxKm / DegreeToKilometer
+	minLat, maxLat := math.Max(lat-deltaLat, -90), math.Min(lat+deltaLat, 90)
+	// the degrees of longitude needed are greatest nearest the pole
+	deltaLon := LongitudeKilometerDegrees(math.Max(math.Abs(minLat), math.Abs(maxLat)), maxKm)
+	from, cols := 0, g.cols
+	if deltaLon < 180 {
+		from = g.column(lon - deltaLon)
+		west, east := math.Floor((lon-deltaLon+180)/g.cellDeg), math.Floor((lon+deltaLon+180)/g.cellDeg)
+		if span := int(east-west) + 1; span < cols {
+			cols = span
+		}
+	}
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	best, closest := -1, -1.0
+	for y := g.row(minLat); y <= g.row(maxLat); y++ {
+		for i := 0; i < cols; i++ {
+			x := (from + i) % g.cols
+			for _, e := range g.cells[gridCell{y, x}] {
+				dist := pt.Distance(e.pt)
+				if !(dist <= maxKm) {
+					continue
+				}
+				if best < 0 || dist < closest || (dist == closest && e.id < best) {
+					best, closest = e.id, dist
+				}
+			}
+		}
+	}
+	return best, closest
+}
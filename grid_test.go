@@ -0,0 +1,142 @@
+package geo
+
+import (
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGrid(t *testing.T) {
+	pts := samplePoints()
+	grid := NewGrid(0.05)
+	for i, pt := range pts {
+		grid.Insert(i, pt)
+	}
+	assert.Equal(t, len(pts), grid.Len())
+
+	pt := GeoPoint(AlaLat+0.051, AlaLon+0.049)
+	want, wantDist := Bestest(pts, pt, 1.0)
+	id, dist := grid.Nearest(pt, 1.0)
+	assert.Equal(t, want, id)
+	assert.Equal(t, wantDist, dist)
+
+	// once it's gone, something else is nearest
+	assert.True(t, grid.Remove(want))
+	assert.False(t, grid.Remove(want))
+	id, dist = grid.Nearest(pt, 1.0)
+	assert.NotEqual(t, want, id)
+	assert.Greater(t, dist, wantDist)
+
+	// moving a point replaces it
+	grid.Insert(id, GeoPoint(0, 0))
+	assert.Equal(t, len(pts)-1, grid.Len())
+	moved, _ := grid.Nearest(GeoPoint(0, 0), 1.0)
+	assert.Equal(t, id, moved)
+
+	id, dist = grid.Nearest(GeoPoint(10, 10), 1.0)
+	assert.Equal(t, -1, id)
+	assert.Equal(t, -1.0, dist)
+
+	assert.Panics(t, func() { NewGrid(0) })
+	assert.Panics(t, func() { NewGrid(-0.05) })
+	assert.Panics(t, func() { NewGrid(math.NaN()) })
+
+	// not a point
+	id, dist = grid.Nearest(Point{Lat: GeoType(math.NaN())}, 1.0)
+	assert.Equal(t, -1, id)
+	assert.Equal(t, -1.0, dist)
+}
+
+func TestGridPoles(t *testing.T) {
+	grid := NewGrid(0.05)
+	grid.Insert(1, GeoPoint(89.99, 45))
+	grid.Insert(2, GeoPoint(-89.99, -45))
+	grid.Insert(3, GeoPoint(60, 0))
+
+	// every longitude is within range of the pole itself
+	id, dist := grid.Nearest(GeoPoint(90, 0), 5)
+	assert.Equal(t, 1, id)
+	assert.InDelta(t, 1.11, dist, 0.01)
+	id, _ = grid.Nearest(GeoPoint(-90, 0), 5)
+	assert.Equal(t, 2, id)
+	// even across the pole
+	id, _ = grid.Nearest(GeoPoint(89.99, -135), 5)
+	assert.Equal(t, 1, id)
+	id, _ = grid.Nearest(GeoPoint(90, 0), 0.5)
+	assert.Equal(t, -1, id)
+}
+
+func TestGridAntimeridian(t *testing.T) {
+	grid := NewGrid(0.05)
+	grid.Insert(1, GeoPoint(10, 179.999))
+	grid.Insert(2, GeoPoint(10, -179.99))
+	grid.Insert(3, GeoPoint(10, 179.9))
+
+	id, dist := grid.Nearest(GeoPoint(10, -179.999), 1)
+	assert.Equal(t, 1, id)
+	assert.Less(t, dist, 0.25)
+	id, _ = grid.Nearest(GeoPoint(10, 180), 1)
+	assert.Equal(t, 1, id)
+	id, _ = grid.Nearest(GeoPoint(10, 179.985), 2)
+	assert.Equal(t, 1, id)
+	id, _ = grid.Nearest(GeoPoint(10, -179.98), 1.2)
+	assert.Equal(t, 2, id)
+
+	// a cell size that doesn't divide the world evenly
+	grid = NewGrid(0.7)
+	grid.Insert(1, GeoPoint(10, 179.9))
+	id, _ = grid.Nearest(GeoPoint(10, -179.9), 25)
+	assert.Equal(t, 1, id)
+}
+
+func TestGridExactMatch(t *testing.T) {
+	pt := GeoPoint(12.75, 21.675)
+	grid := NewGrid(0.05)
+	grid.Insert(1, GeoPoint(12.76, 21.675))
+	grid.Insert(2, pt)
+	id, dist := grid.Nearest(pt, 5)
+	assert.Equal(t, 2, id)
+	assert.Equal(t, 0.0, dist)
+
+	// ties go to the lowest id, whatever order they went in
+	for _, order := range [][]int{{3, 4, 5}, {5, 4, 3}, {4, 5, 3}} {
+		grid := NewGrid(0.05)
+		for _, id := range order {
+			grid.Insert(id, pt)
+		}
+		grid.Remove(order[0])
+		grid.Insert(order[0], pt)
+		id, _ := grid.Nearest(pt, 5)
+		assert.Equal(t, 3, id, "order %v", order)
+	}
+}
+
+// run with -race to make sure it's legit
+func TestGridConcurrent(t *testing.T) {
+	pts := samplePoints()
+	grid := NewGrid(0.05)
+	for i, pt := range pts {
+		grid.Insert(i, pt)
+	}
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(2)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < len(pts); i += 4 {
+				grid.Remove(i)
+				grid.Insert(i, pts[(i+1)%len(pts)])
+			}
+		}(w)
+		go func() {
+			defer wg.Done()
+			for _, pt := range pts {
+				grid.Nearest(pt, 0.5)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, len(pts), grid.Len())
+}
@@ -0,0 +1,54 @@
+package geo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// pointJSON is the object form of a Point
+type pointJSON struct {
+	Lat *float64 `json:"lat"`
+	Lon *float64 `json:"lon"`
+}
+
+// MarshalJSON encodes the point as {"lat":..,"lon":..}
+func (p Point) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Lat GeoType `json:"lat"`
+		Lon GeoType `json:"lon"`
+	}{p.Lat, p.Lon})
+}
+
+// UnmarshalJSON decodes either the object form {"lat":..,"lon":..}
+// or the GeoJSON coordinate array form [lon,lat]
+// (note the order, which is the reverse of everywhere else)
+func (p *Point) UnmarshalJSON(b []byte) error {
+	var lat, lon float64
+	b = bytes.TrimSpace(b)
+	if len(b) > 0 && b[0] == '[' {
+		var coords []float64
+		if err := json.Unmarshal(b, &coords); err != nil {
+			return err
+		}
+		// GeoJSON allows an optional altitude
+		if len(coords) < 2 || len(coords) > 3 {
+			return fmt.Errorf("expected [lon,lat] but got %s: %w", b, ErrInvalidCoordinates)
+		}
+		lon, lat = coords[0], coords[1]
+	} else {
+		var obj pointJSON
+		if err := json.Unmarshal(b, &obj); err != nil {
+			return err
+		}
+		if obj.Lat == nil || obj.Lon == nil {
+			return fmt.Errorf("missing lat or lon in %s: %w", b, ErrInvalidCoordinates)
+		}
+		lat, lon = *obj.Lat, *obj.Lon
+	}
+	if !validCoords(lat, lon) {
+		return fmt.Errorf("coordinates out of range %s: %w", b, ErrInvalidCoordinates)
+	}
+	*p = GeoPoint(lat, lon)
+	return nil
+}
@@ -0,0 +1,58 @@
+package geo
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPointJSON(t *testing.T) {
+	pt := GeoPoint(AlaLat, AlaLon)
+	b, err := json.Marshal(pt)
+	assert.NoError(t, err)
+	t.Logf("json: %s", b)
+
+	var got Point
+	assert.NoError(t, json.Unmarshal(b, &got))
+	assert.Equal(t, pt, got)
+
+	// GeoJSON order, with and without altitude
+	for _, text := range []string{
+		`[-122.25699, 37.77034]`,
+		`[-122.25699, 37.77034, 12.5]`,
+		` {"lon": -122.25699, "lat": 37.77034} `,
+	} {
+		got = Point{}
+		assert.NoError(t, json.Unmarshal([]byte(text), &got), text)
+		assert.Equal(t, GeoPoint(37.77034, -122.25699), got, text)
+	}
+
+	// embedded in something else
+	var places struct {
+		Home Point   `json:"home"`
+		Path []Point `json:"path"`
+	}
+	text := `{"home":{"lat":1.5,"lon":2.5},"path":[[2.5,1.5],{"lat":3,"lon":4}]}`
+	assert.NoError(t, json.Unmarshal([]byte(text), &places))
+	assert.Equal(t, GeoPoint(1.5, 2.5), places.Home)
+	assert.Equal(t, []Point{GeoPoint(1.5, 2.5), GeoPoint(3, 4)}, places.Path)
+}
+
+func TestPointJSONErrors(t *testing.T) {
+	for _, text := range []string{
+		`[1]`,
+		`[1,2,3,4]`,
+		`{"lat":1}`,
+		`{"lat":91,"lon":0}`,
+		`[0,91]`,
+	} {
+		var pt Point
+		err := json.Unmarshal([]byte(text), &pt)
+		assert.ErrorIs(t, err, ErrInvalidCoordinates, text)
+	}
+	for _, text := range []string{`[1,`, `"1,2"`, `{"lat":"x","lon":2}`} {
+		var pt Point
+		assert.Error(t, json.Unmarshal([]byte(text), &pt), text)
+	}
+}
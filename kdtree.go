@@ -0,0 +1,148 @@
+package geo
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+)
+
+// KDTree is an index for nearest neighbor searches over unsorted points.
+//
+// The points are stored as vectors on the unit sphere, where the
+// (straight line) distance between them always orders the same as the
+// great circle distance, with no trouble at the poles or the antimeridian
+type KDTree struct {
+	pts   []Point
+	vecs  []vector
+	nodes []kdNode
+	root  int
+}
+
+type kdNode struct {
+	idx         int // of the point
+	axis        int // of the split
+	left, right int // of the child nodes, -1 if none
+}
+
+// NewKDTree returns a tree built over the points, which are not modified.
+// The indexes returned by searches are of the points given
+func NewKDTree(pts []Point) *KDTree {
+	t := &KDTree{
+		pts:   pts,
+		vecs:  make([]vector, len(pts)),
+		nodes: make([]kdNode, 0, len(pts)),
+	}
+	idx := make([]int, len(pts))
+	for i, pt := range pts {
+		t.vecs[i] = toVector(pt)
+		idx[i] = i
+	}
+	t.root = t.build(idx, 0)
+	return t
+}
+
+// build returns the node for the (median of) the points, or -1 if there are none
+func (t *KDTree) build(idx []int, depth int) int {
+	if len(idx) == 0 {
+		return -1
+	}
+	axis := depth % 3
+	sort.Slice(idx, func(i, j int) bool {
+		return t.vecs[idx[i]][axis] < t.vecs[idx[j]][axis]
+	})
+	mid := len(idx) / 2
+	node := len(t.nodes)
+	t.nodes = append(t.nodes, kdNode{idx: idx[mid], axis: axis})
+	left := t.build(idx[:mid], depth+1)
+	right := t.build(idx[mid+1:], depth+1)
+	t.nodes[node].left, t.nodes[node].right = left, right
+	return node
+}
+
+// Len returns the number of points in the tree
+func (t *KDTree) Len() int {
+	return len(t.pts)
+}
+
+// chordSq is the square of the straight line distance between the vectors
+func chordSq(a, b vector) float64 {
+	dx, dy, dz := a[0]-b[0], a[1]-b[1], a[2]-b[2]
+	return dx*dx + dy*dy + dz*dz
+}
+
+// chordKm returns the great circle distance (in Km) for the squared chord,
+// which is the haversine formula, and unlike Distance, is stable
+// for points that are (nearly) the same
+func chordKm(sq float64) float64 {
+	return 2 * math.Asin(math.Min(1, math.Sqrt(sq)/2)) * EarthRadiusInKM
+}
+
+// Nearest returns the index of the point nearest to pt, and its distance (in Km).
+// It returns -1, -1 if the tree is empty
+func (t *KDTree) Nearest(pt Point) (int, float64) {
+	idx, dists := t.KNearest(pt, 1)
+	if len(idx) == 0 {
+		return -1, -1
+	}
+	return idx[0], dists[0]
+}
+
+// nearestFirst is a max-heap of candidates, so the furthest can be replaced
+type nearestFirst []candidate
+
+func (n nearestFirst) Len() int            { return len(n) }
+func (n nearestFirst) Less(i, j int) bool  { return n[i].dist > n[j].dist }
+func (n nearestFirst) Swap(i, j int)       { n[i], n[j] = n[j], n[i] }
+func (n *nearestFirst) Push(x interface{}) { *n = append(*n, x.(candidate)) }
+func (n *nearestFirst) Pop() interface{} {
+	old := *n
+	last := old[len(old)-1]
+	*n = old[:len(old)-1]
+	return last
+}
+
+// KNearest returns the indexes of the k points nearest to pt,
+// and their distances (in Km), closest first
+func (t *KDTree) KNearest(pt Point, k int) ([]int, []float64) {
+	if k < 1 || t.root < 0 {
+		return nil, nil
+	}
+	target := toVector(pt)
+	best := make(nearestFirst, 0, k)
+
+	var visit func(node int)
+	visit = func(node int) {
+		if node < 0 {
+			return
+		}
+		n := t.nodes[node]
+		switch d := chordSq(target, t.vecs[n.idx]); {
+		case len(best) < k:
+			heap.Push(&best, candidate{n.idx, d})
+		case d < best[0].dist:
+			best[0] = candidate{n.idx, d}
+			heap.Fix(&best, 0)
+		}
+
+		diff := target[n.axis] - t.vecs[n.idx][n.axis]
+		near, far := n.left, n.right
+		if diff > 0 {
+			near, far = far, near
+		}
+		visit(near)
+		// the other side can only be closer if the split is
+		if len(best) < k || diff*diff < best[0].dist {
+			visit(far)
+		}
+	}
+	visit(t.root)
+
+	idx := make([]int, len(best))
+	dists := make([]float64, len(best))
+	for i := len(best) - 1; i >= 0; i-- {
+		c := heap.Pop(&best).(candidate)
+		idx[i] = c.idx
+		dists[i] = chordKm(c.dist)
+	}
+	return idx, dists
+}
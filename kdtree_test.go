@@ -0,0 +1,76 @@
+package geo
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKDTree(t *testing.T) {
+	r := rand.New(rand.NewSource(7))
+	for _, n := range []int{1, 2, 10, 1000} {
+		pts := randomPoints(r, n, 1.0)
+		tree := NewKDTree(pts)
+		assert.Equal(t, n, tree.Len())
+		for i := 0; i < 50; i++ {
+			pt := GeoPoint(AlaLat+r.Float64()*1.2-0.1, AlaLon+r.Float64()*1.2-0.1)
+			all := make(candidates, len(pts))
+			for j, x := range pts {
+				all[j] = candidate{j, pt.Distance(x)}
+			}
+			sort.Stable(all)
+
+			idx, dist := tree.Nearest(pt)
+			assert.Equal(t, all[0].idx, idx, "%v", pt)
+			assert.InDelta(t, all[0].dist, dist, 1e-6)
+
+			k := 5
+			if k > n {
+				k = n
+			}
+			kidx, kdists := tree.KNearest(pt, 5)
+			assert.Len(t, kidx, k)
+			for j := range kidx {
+				assert.InDelta(t, all[j].dist, kdists[j], 1e-6)
+				assert.InDelta(t, all[j].dist, pt.Distance(pts[kidx[j]]), 1e-6)
+			}
+		}
+	}
+
+	// around the antimeridian
+	pts := []Point{GeoPoint(0, 179.9), GeoPoint(0, -179.95), GeoPoint(0, 170)}
+	idx, dist := NewKDTree(pts).Nearest(GeoPoint(0, -179.99))
+	assert.Equal(t, 1, idx)
+	assert.InDelta(t, 0.04*EarthRadiusInKM*Radian, dist, 1e-3)
+
+	// exactly on a point
+	idx, dist = NewKDTree(pts).Nearest(pts[2])
+	assert.Equal(t, 2, idx)
+	assert.Equal(t, 0.0, dist)
+
+	idx, dist = NewKDTree(nil).Nearest(pts[0])
+	assert.Equal(t, -1, idx)
+	assert.Equal(t, -1.0, dist)
+	kidx, _ := NewKDTree(pts).KNearest(pts[0], 0)
+	assert.Empty(t, kidx)
+}
+
+func BenchmarkKDTree(b *testing.B) {
+	pt, list := searchSample(b, false)
+	pts := []Point(list.(testPoints))
+	tree := NewKDTree(pts)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.Nearest(pt)
+	}
+}
+
+func BenchmarkKDTreeBestest(b *testing.B) {
+	pt, list := searchSample(b, false)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Bestest(list, pt, 0.2)
+	}
+}
@@ -0,0 +1,169 @@
+package geo
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Layout is the format of the records in a file built by BuildFileLayout,
+// which is kept in the header of the file
+type Layout byte
+
+const (
+	LayoutCustom  Layout = iota // whatever the records Encode themselves as
+	LayoutFloat32               // 8 bytes: lat, lon as float32 (little endian)
+	LayoutFloat64               // 16 bytes: lat, lon as float64 (little endian)
+)
+
+// Size returns the size of each record, or 0 for a custom layout
+func (l Layout) Size() int {
+	switch l {
+	case LayoutFloat32:
+		return 8
+	case LayoutFloat64:
+		return 16
+	}
+	return 0
+}
+
+// Decoder returns a new Decoder for the layout, or nil for a custom layout
+func (l Layout) Decoder() Decoder {
+	switch l {
+	case LayoutFloat32:
+		return &Float32Record{}
+	case LayoutFloat64:
+		return &Float64Record{}
+	}
+	return nil
+}
+
+// Pairer is implemented by records that have coordinates
+// more precise than a Point (i.e., float64)
+type Pairer interface {
+	Pair() Pair
+}
+
+// pairOf returns the most precise coordinates of the record
+func pairOf(r Encoder) Pair {
+	if p, ok := r.(Pairer); ok {
+		return p.Pair()
+	}
+	pt := r.Point()
+	return Pair{float64(pt.Lat), float64(pt.Lon)}
+}
+
+// byteOrder returns the order, or little endian if it hasn't been set
+func byteOrder(order binary.ByteOrder) binary.ByteOrder {
+	if order == nil {
+		return binary.LittleEndian
+	}
+	return order
+}
+
+// Float32Record is the record of LayoutFloat32.
+// A float32 has about 7 digits of precision, which is within ~11cm
+// for coordinates under 16 degrees, and under a meter at worst
+type Float32Record struct {
+	Pt    Point
+	order binary.ByteOrder
+}
+
+// SetByteOrder sets the byte order of the record (little endian by default)
+func (r *Float32Record) SetByteOrder(order binary.ByteOrder) {
+	r.order = order
+}
+
+func (r *Float32Record) Decode(b []byte) error {
+	r.Pt = DecodePointOrder(b, byteOrder(r.order))
+	return nil
+}
+
+func (r *Float32Record) Encode(b []byte) error {
+	order := byteOrder(r.order)
+	order.PutUint32(b, math.Float32bits(float32(r.Pt.Lat)))
+	order.PutUint32(b[4:], math.Float32bits(float32(r.Pt.Lon)))
+	return nil
+}
+
+func (r *Float32Record) Size() int {
+	return LayoutFloat32.Size()
+}
+
+func (r *Float32Record) Point() Point {
+	return r.Pt
+}
+
+func (r *Float32Record) JSON(w io.Writer) error {
+	_, err := fmt.Fprintf(w, `{"lat":%v,"lon":%v}`, r.Pt.Lat, r.Pt.Lon)
+	return err
+}
+
+// Float64Record is the record of LayoutFloat64,
+// for when the precision of a float32 is not enough
+type Float64Record struct {
+	Coords Pair
+	order  binary.ByteOrder
+}
+
+// SetByteOrder sets the byte order of the record (little endian by default)
+func (r *Float64Record) SetByteOrder(order binary.ByteOrder) {
+	r.order = order
+}
+
+func (r *Float64Record) Decode(b []byte) error {
+	r.Coords = DecodePairOrder(b, byteOrder(r.order))
+	return nil
+}
+
+func (r *Float64Record) Encode(b []byte) error {
+	order := byteOrder(r.order)
+	order.PutUint64(b, math.Float64bits(r.Coords[0]))
+	order.PutUint64(b[8:], math.Float64bits(r.Coords[1]))
+	return nil
+}
+
+func (r *Float64Record) Size() int {
+	return LayoutFloat64.Size()
+}
+
+func (r *Float64Record) Point() Point {
+	return GeoPoint(r.Coords[0], r.Coords[1])
+}
+
+// Pair returns the full precision coordinates
+func (r *Float64Record) Pair() Pair {
+	return r.Coords
+}
+
+func (r *Float64Record) JSON(w io.Writer) error {
+	_, err := fmt.Fprintf(w, `{"lat":%v,"lon":%v}`, r.Coords[0], r.Coords[1])
+	return err
+}
+
+// record returns a record in the layout with the coordinates of r
+func (l Layout) record(r Encoder) Encoder {
+	switch l {
+	case LayoutFloat32:
+		return &Float32Record{Pt: r.Point()}
+	case LayoutFloat64:
+		return &Float64Record{Coords: pairOf(r)}
+	}
+	return r
+}
+
+// NewLayoutIter returns an iterator over a file built with one of the
+// standard layouts, using the Decoder for that layout
+func (m *MFile) NewLayoutIter() (*Iter, error) {
+	hdr, ok := readHeader(m.B)
+	if !ok {
+		return nil, errors.New("file has no header")
+	}
+	d := Layout(hdr.Flags).Decoder()
+	if d == nil {
+		return nil, fmt.Errorf("file does not have a standard layout (%d)", hdr.Flags)
+	}
+	return m.NewIter(d), nil
+}
@@ -0,0 +1,112 @@
+package geo
+
+import (
+	"encoding/binary"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLayouts(t *testing.T) {
+	// more precise than a float32 can hold
+	var records []Encoder
+	var pairs []Pair
+	for i := 0; i < 100; i++ {
+		pair := Pair{AlaLat + float64(i)*0.0123456789, AlaLon - float64(i)*0.0098765432}
+		pairs = append(pairs, pair)
+		records = append(records, &Float64Record{Coords: pair})
+	}
+	records = append(records, &pointRecord{GeoPoint(1.5, 2.5)})
+	pairs = append(pairs, Pair{1.5, 2.5})
+
+	dir := t.TempDir()
+	for _, layout := range []Layout{LayoutFloat32, LayoutFloat64} {
+		filename := filepath.Join(dir, "points.bin")
+		if err := BuildFileLayout(filename, records, layout); err != nil {
+			t.Fatal(err)
+		}
+		m, err := Mmap(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, headerSize+len(records)*layout.Size(), len(m.B))
+		iter, err := m.NewLayoutIter()
+		assert.NoError(t, err)
+		assert.Equal(t, len(records), iter.Len())
+		assert.True(t, IsSorted(iter))
+
+		for _, pair := range pairs {
+			pt := GeoPoint(pair[0], pair[1])
+			idx, _ := Bestest(iter, GeoPoint(pair[0]+0.0001, pair[1]), 1)
+			assert.Equal(t, pt, iter.IndexPoint(idx))
+
+			rec := iter.Get(idx)
+			switch layout {
+			case LayoutFloat32:
+				// at these coordinates a float32 is within half a meter
+				got := rec.(*Float32Record).Pt
+				assert.InDelta(t, pair[0], float64(got.Lat), 0.000002)
+				assert.InDelta(t, pair[1], float64(got.Lon), 0.000004)
+			case LayoutFloat64:
+				assert.Equal(t, pair, rec.(*Float64Record).Pair())
+			}
+		}
+		m.Close()
+	}
+
+	// closer to 0,0 a float32 is within ~11cm (~1e-6 degrees)
+	for _, f := range []float64{1.23456789, 9.87654321, 15.9999999} {
+		assert.InDelta(t, f, float64(GeoType(f)), 0.000001)
+	}
+}
+
+func TestLayoutByteOrder(t *testing.T) {
+	pts := samplePoints()
+	for _, layout := range []Layout{LayoutFloat32, LayoutFloat64} {
+		size := layout.Size()
+		buf := make([]byte, size*len(pts))
+		for i, pt := range pts {
+			rec := layout.Decoder().(EndianDecoder)
+			rec.SetByteOrder(binary.BigEndian)
+			switch r := rec.(type) {
+			case *Float32Record:
+				r.Pt = pt
+			case *Float64Record:
+				r.Coords = Pair{float64(pt.Lat), float64(pt.Lon)}
+			}
+			assert.NoError(t, rec.(Encoder).Encode(buf[i*size:]))
+		}
+		// it really is big endian
+		if layout == LayoutFloat32 {
+			assert.Equal(t, pts[0], DecodePointOrder(buf, binary.BigEndian))
+		} else {
+			assert.Equal(t, Pair{float64(pts[0].Lat), float64(pts[0].Lon)}, DecodePairOrder(buf, binary.BigEndian))
+		}
+
+		m := &MFile{B: buf}
+		iter, err := m.NewIterOrder(layout.Decoder(), binary.BigEndian)
+		assert.NoError(t, err)
+		assert.Equal(t, len(pts), iter.Len())
+		for i, pt := range pts {
+			assert.Equal(t, pt, iter.IndexPoint(i), "%v", layout)
+		}
+	}
+}
+
+func TestLayoutCustom(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "points.bin")
+	if err := BuildFile(filename, []Encoder{&pointRecord{}}); err != nil {
+		t.Fatal(err)
+	}
+	m, err := Mmap(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	_, err = m.NewLayoutIter()
+	assert.Error(t, err)
+
+	_, err = (&MFile{B: encodePoints(samplePoints())}).NewLayoutIter()
+	assert.Error(t, err)
+}
@@ -1,8 +1,15 @@
 package geo
 
 import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
 	"errors"
+	"fmt"
 	"io"
+	"math"
+	"os"
+	"path/filepath"
 	"sort"
 
 	"github.com/tidwall/mmap"
@@ -18,36 +25,55 @@ type Decoder interface {
 	JSON(w io.Writer) error
 }
 
+// EndianDecoder is a Decoder that can read records in either byte order
+// (little endian being the default), for files from big endian systems
+type EndianDecoder interface {
+	Decoder
+	SetByteOrder(binary.ByteOrder)
+}
+
 type MFile struct {
-	B []byte
+	B    []byte
+	temp string // backing file to remove on close
 }
 
 type Iter struct {
-	m *MFile
-	d Decoder
+	m   *MFile
+	d   Decoder
+	off int // where the records start (i.e., after any header)
 }
 
 func (m *MFile) Close() error {
-	return mmap.Close(m.B)
+	err := mmap.Close(m.B)
+	if m.temp != "" {
+		if rerr := os.Remove(m.temp); err == nil {
+			err = rerr
+		}
+	}
+	return err
 }
 
 func (m *Iter) Len() int {
-	return len(m.m.B) / m.d.Size()
+	return (len(m.m.B) - m.off) / m.d.Size()
 }
 
-func (m *Iter) IndexPoint(i int) Point {
-	off := m.d.Size() * i
+// Bytes returns the raw bytes of the record at index i.
+//
+// NOTE: this is a slice of the mapped file itself, not a copy,
+// so it must not be modified, and is only valid until the MFile is closed
+func (m *Iter) Bytes(i int) []byte {
+	off := m.off + m.d.Size()*i
 	end := off + m.d.Size()
-	if err := m.d.Decode(m.m.B[off:end]); err != nil {
-		panic(err)
-	}
+	return m.m.B[off:end:end]
+}
+
+func (m *Iter) IndexPoint(i int) Point {
+	m.Load(i)
 	return m.d.Point()
 }
 
 func (m *Iter) Load(i int) {
-	off := m.d.Size() * i
-	end := off + m.d.Size()
-	if err := m.d.Decode(m.m.B[off:end]); err != nil {
+	if err := m.d.Decode(m.Bytes(i)); err != nil {
 		panic(err)
 	}
 }
@@ -65,7 +91,50 @@ func Mmap(filename string) (*MFile, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &MFile{b}, err
+	return &MFile{B: b}, err
+}
+
+// OpenGzip decompresses a gzipped file of records into a temporary file
+// and maps that, so an Iter works on it just as it would with Mmap.
+//
+// Unlike Mmap, the full uncompressed data is written out up front,
+// so it costs the time to decompress it and the equivalent disk space
+// (and page cache) for as long as the MFile is open.
+// The temporary file is removed by Close.
+func OpenGzip(filename string) (*MFile, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	gzr, err := gzip.NewReader(bufio.NewReader(f))
+	if err != nil {
+		return nil, err
+	}
+	defer gzr.Close()
+
+	tmp, err := os.CreateTemp("", filepath.Base(filename)+".*")
+	if err != nil {
+		return nil, err
+	}
+	defer tmp.Close()
+
+	if _, err := io.Copy(tmp, gzr); err != nil {
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+	m, err := Mmap(tmp.Name())
+	if err != nil {
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+	m.temp = tmp.Name()
+	return m, nil
 }
 
 func (m *MFile) ReadAt(p []byte, i int64) (int, error) {
@@ -75,27 +144,146 @@ func (m *MFile) ReadAt(p []byte, i int64) (int, error) {
 	return copy(p, m.B[i:]), nil
 }
 
+// NewIter returns an iterator over the records in the file,
+// skipping the header if it was created by BuildFile
 func (m *MFile) NewIter(d Decoder) *Iter {
+	var off int
+	if _, ok := readHeader(m.B); ok {
+		off = headerSize
+	}
 	return &Iter{
-		m: m,
-		d: d,
+		m:   m,
+		d:   d,
+		off: off,
 	}
 }
 
-func (m *Iter) Get(i int) interface{} {
-	off := m.d.Size() * i
-	end := off + m.d.Size()
-	if err := m.d.Decode(m.m.B[off:end]); err != nil {
-		panic(err)
+// NewIterOrder is NewIter for records in the given byte order,
+// which requires a Decoder that implements EndianDecoder
+// (unless the order is little endian)
+func (m *MFile) NewIterOrder(d Decoder, order binary.ByteOrder) (*Iter, error) {
+	if ed, ok := d.(EndianDecoder); ok {
+		ed.SetByteOrder(order)
+	} else if order != binary.ByteOrder(binary.LittleEndian) {
+		return nil, fmt.Errorf("%T does not support %v byte order", d, order)
 	}
+	return m.NewIter(d), nil
+}
+
+// Get returns the Decoder loaded with the record at index i.
+//
+// NOTE: the Decoder is shared by every call, so the result
+// is only valid until the next call that loads a record
+// (including IndexPoint). Use PointCopy if only the point is needed.
+func (m *Iter) Get(i int) interface{} {
+	m.Load(i)
 	return m.d
 }
 
+// PointCopy returns a copy of the point of the record at index i,
+// which, unlike the result of Get, remains valid after other records are loaded
+func (m *Iter) PointCopy(i int) Point {
+	return m.IndexPoint(i)
+}
+
 type Container interface {
 	ContainsPoint(Point) bool
 }
 
 func (m *Iter) Ranger(from, to Point, fn func(interface{}), ctr Container) error {
+	return m.ranger(from, to, func(int) bool {
+		fn(m.d)
+		return true
+	}, ctr)
+}
+
+// RangerFilter is Ranger, but only calls fn with the records
+// that keep (given the same decoded record) returns true for,
+// to filter on the record's other attributes
+func (m *Iter) RangerFilter(from, to Point, fn func(interface{}), ctr Container, keep func(interface{}) bool) error {
+	return m.ranger(from, to, func(int) bool {
+		if keep(m.d) {
+			fn(m.d)
+		}
+		return true
+	}, ctr)
+}
+
+// RangerLimit is Ranger, but stops after fn has been called limit times
+// (if limit is greater than zero), and returns how many times it was called
+func (m *Iter) RangerLimit(from, to Point, limit int, fn func(interface{}), ctr Container) (int, error) {
+	var count int
+	err := m.ranger(from, to, func(int) bool {
+		fn(m.d)
+		count++
+		return limit <= 0 || count < limit
+	}, ctr)
+	return count, err
+}
+
+// RangerStride is Ranger, but only calls fn with every stride-th record
+// found (starting with the first), e.g., for a sparse preview
+func (m *Iter) RangerStride(from, to Point, stride int, fn func(interface{}), ctr Container) error {
+	if stride < 1 {
+		return fmt.Errorf("stride must be at least 1, not %d", stride)
+	}
+	var count int
+	return m.ranger(from, to, func(int) bool {
+		if count%stride == 0 {
+			fn(m.d)
+		}
+		count++
+		return true
+	}, ctr)
+}
+
+// RangerNDJSON writes each record found by Ranger to w as JSON,
+// one record per line (newline delimited JSON), stopping at the
+// first write error
+func (m *Iter) RangerNDJSON(w io.Writer, from, to Point, ctr Container) error {
+	var werr error
+	err := m.ranger(from, to, func(int) bool {
+		if werr = m.d.JSON(w); werr == nil {
+			_, werr = io.WriteString(w, "\n")
+		}
+		return werr == nil
+	}, ctr)
+	if werr != nil {
+		return werr
+	}
+	return err
+}
+
+// GeohashIndex returns the indices of the records in each geohash cell
+// of the given precision, keyed by the geohash, in a single pass.
+//
+// NOTE: this holds an index for every record (plus a key per cell)
+// in memory, so for large files it can be costly
+func (m *Iter) GeohashIndex(precision int) map[string][]int {
+	index := make(map[string][]int)
+	for i := 0; i < m.Len(); i++ {
+		hash := m.IndexPoint(i).Geohash(precision)
+		index[hash] = append(index[hash], i)
+	}
+	return index
+}
+
+// InPolygon calls fn with each record inside the polygon,
+// scanning only the records within the polygon's bounding box
+func (m *Iter) InPolygon(poly Polygon, fn func(interface{})) error {
+	box, ok := BoundsOf(poly)
+	if !ok {
+		return ErrNotFound
+	}
+	from := GeoPoint(box[0][0], box[0][1])
+	to := GeoPoint(box[1][0], box[1][1])
+	return m.Ranger(from, to, fn, poly)
+}
+
+// ranger calls fn with the index of each record within the box from..to
+// (and ctr, if given), with the record loaded in the decoder,
+// until fn returns false
+func (m *Iter) ranger(from, to Point, fn func(int) bool, ctr Container) error {
 	size := m.Len()
 	idx := sort.Search(size, func(i int) bool {
 		return from.Less(m.IndexPoint(i))
@@ -103,7 +291,7 @@ func (m *Iter) Ranger(from, to Point, fn func(interface{}), ctr Container) error
 	if idx == size {
 		return ErrNotFound
 	}
-	for {
+	for ; idx < size; idx++ {
 		m.Load(idx)
 		if !m.Less(to) {
 			break
@@ -111,10 +299,128 @@ func (m *Iter) Ranger(from, to Point, fn func(interface{}), ctr Container) error
 		pt := m.d.Point()
 		if between(pt.Lon, from.Lon, to.Lon) {
 			if ctr == nil || ctr.ContainsPoint(m.d.Point()) {
-				fn(m.d)
+				if !fn(idx) {
+					break
+				}
 			}
 		}
-		idx++
 	}
 	return nil
 }
+
+// NearestInBox returns the record within the box that is closest to pt,
+// and its distance. Only the records in the box are examined.
+// It returns ErrNotFound if there are no records in the box.
+//
+// NOTE: the record returned is the Iter's Decoder (see Get)
+func (m *Iter) NearestInBox(box Rect, pt Point) (interface{}, float64, error) {
+	from := GeoPoint(box[0][0], box[0][1])
+	to := GeoPoint(box[1][0], box[1][1])
+	best, closest := -1, 0.0
+	err := m.ranger(from, to, func(i int) bool {
+		if dist := pt.Distance(m.d.Point()); best < 0 || dist < closest {
+			best, closest = i, dist
+		}
+		return true
+	}, nil)
+	if err != nil {
+		return nil, -1, err
+	}
+	if best < 0 {
+		return nil, -1, ErrNotFound
+	}
+	return m.Get(best), closest, nil
+}
+
+// NearestBytes returns the raw bytes of the record closest to pt
+// (within deltaKm), and its distance, or ErrNotFound if there is none.
+//
+// NOTE: as with Bytes, this is a slice of the mapped file, so it must
+// be copied if it is needed after the MFile is closed
+func (m *Iter) NearestBytes(pt Point, deltaKm float64) ([]byte, float64, error) {
+	idx, dist := Bestest(m, pt, deltaKm)
+	if dist < 0 {
+		return nil, -1, ErrNotFound
+	}
+	return m.Bytes(idx), dist, nil
+}
+
+// CountDuplicates returns how many records have the same coordinates
+// as the record before them, i.e., three records at one point count as two
+func (m *Iter) CountDuplicates() int {
+	var dupes int
+	var prev Point
+	for i := 0; i < m.Len(); i++ {
+		pt := m.IndexPoint(i)
+		if i > 0 && pt == prev {
+			dupes++
+		}
+		prev = pt
+	}
+	return dupes
+}
+
+// VarIter is like Iter, but for records of varying length,
+// which are located by an index of their offsets into the data
+type VarIter struct {
+	m       *MFile
+	d       Decoder
+	offsets []int64
+}
+
+// NewVarIter returns an iterator over variable length records,
+// where offsets has the starting offset of each record in the data
+// (in sorted order, like the records themselves).
+// The decoder's Size() is ignored, as each record runs to the start of the next.
+func (m *MFile) NewVarIter(d Decoder, offsets []int64) *VarIter {
+	return &VarIter{
+		m:       m,
+		d:       d,
+		offsets: offsets,
+	}
+}
+
+func (m *VarIter) Len() int {
+	return len(m.offsets)
+}
+
+func (m *VarIter) record(i int) []byte {
+	end := int64(len(m.m.B))
+	if i+1 < len(m.offsets) {
+		end = m.offsets[i+1]
+	}
+	return m.m.B[m.offsets[i]:end]
+}
+
+func (m *VarIter) Load(i int) {
+	if err := m.d.Decode(m.record(i)); err != nil {
+		panic(err)
+	}
+}
+
+func (m *VarIter) IndexPoint(i int) Point {
+	m.Load(i)
+	return m.d.Point()
+}
+
+func (m *VarIter) Get(i int) interface{} {
+	m.Load(i)
+	return m.d
+}
+
+// NearestIterator returns a func that yields the records closest to pt,
+// one at a time, nearest first, along with their distances.
+// The bool is false once every record has been returned.
+//
+// NOTE: the record returned is the Iter's Decoder,
+// so it is only valid until the next call
+func (m *Iter) NearestIterator(pt Point) func() (interface{}, float64, bool) {
+	s := newSweep(m, pt, math.Inf(1))
+	return func() (interface{}, float64, bool) {
+		i, dist, ok := s.next()
+		if !ok {
+			return nil, dist, false
+		}
+		return m.Get(i), dist, true
+	}
+}
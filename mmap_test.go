@@ -1,7 +1,21 @@
 package geo
 
 import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 type mmapSample struct {
@@ -10,6 +24,56 @@ type mmapSample struct {
 	Meh float64
 }
 
+// pointRecord is a minimal Decoder for records
+// that are just a pair of float32 coordinates
+type pointRecord struct {
+	pt Point
+}
+
+func (r *pointRecord) Decode(b []byte) error {
+	r.pt = DecodePoint(b)
+	return nil
+}
+
+func (r *pointRecord) Encode(b []byte) error {
+	copy(b, encodePoints([]Point{r.pt}))
+	return nil
+}
+
+func (r *pointRecord) Size() int {
+	return 8
+}
+
+func (r *pointRecord) Point() Point {
+	return r.pt
+}
+
+func (r *pointRecord) JSON(w io.Writer) error {
+	_, err := fmt.Fprintf(w, `{"lat":%f,"lon":%f}`, r.pt.Lat, r.pt.Lon)
+	return err
+}
+
+func encodePoints(pts []Point) []byte {
+	buf := make([]byte, 8*len(pts))
+	for i, pt := range pts {
+		binary.LittleEndian.PutUint32(buf[i*8:], math.Float32bits(float32(pt.Lat)))
+		binary.LittleEndian.PutUint32(buf[i*8+4:], math.Float32bits(float32(pt.Lon)))
+	}
+	return buf
+}
+
+// samplePoints returns a sorted grid of points around Alameda
+func samplePoints() testPoints {
+	var pts testPoints
+	for lat := 0; lat < 20; lat++ {
+		for lon := 0; lon < 20; lon++ {
+			pts = append(pts, GeoPoint(AlaLat+float64(lat)*0.01, AlaLon+float64(lon)*0.01))
+		}
+	}
+	sort.Sort(pts)
+	return pts
+}
+
 func TestMakeSample(t *testing.T) {
 
 }
@@ -17,3 +81,436 @@ func TestMakeSample(t *testing.T) {
 func TestReadAt(t *testing.T) {
 
 }
+
+func TestIterBytes(t *testing.T) {
+	pts := samplePoints()
+	m := &MFile{B: encodePoints(pts)}
+	iter := m.NewIter(&pointRecord{})
+	for _, i := range []int{0, 1, 17, len(pts) - 1} {
+		b := iter.Bytes(i)
+		buf := make([]byte, len(b))
+		n, err := m.ReadAt(buf, int64(i*8))
+		assert.NoError(t, err)
+		assert.Equal(t, 8, n)
+		assert.Equal(t, buf, b)
+		assert.Equal(t, pts[i], DecodePoint(b))
+	}
+}
+
+// endianRecord is a pointRecord that can be read in either byte order
+type endianRecord struct {
+	pointRecord
+	order binary.ByteOrder
+}
+
+func (r *endianRecord) SetByteOrder(order binary.ByteOrder) {
+	r.order = order
+}
+
+func (r *endianRecord) Decode(b []byte) error {
+	r.pt = DecodePointOrder(b, r.order)
+	return nil
+}
+
+func TestByteOrder(t *testing.T) {
+	pts := samplePoints()
+	buf := make([]byte, 8*len(pts))
+	for i, pt := range pts {
+		binary.BigEndian.PutUint32(buf[i*8:], math.Float32bits(float32(pt.Lat)))
+		binary.BigEndian.PutUint32(buf[i*8+4:], math.Float32bits(float32(pt.Lon)))
+	}
+	filename := filepath.Join(t.TempDir(), "big.dat")
+	if err := os.WriteFile(filename, buf, 0644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := Mmap(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	iter, err := m.NewIterOrder(&endianRecord{}, binary.BigEndian)
+	assert.NoError(t, err)
+	assert.Equal(t, len(pts), iter.Len())
+	for i, pt := range pts {
+		assert.Equal(t, pt, iter.IndexPoint(i))
+	}
+	idx, _ := Bestest(iter, pts[17], 1)
+	assert.Equal(t, 17, idx)
+
+	// the default is still little endian
+	little := &MFile{B: encodePoints(pts)}
+	iter, err = little.NewIterOrder(&endianRecord{}, binary.LittleEndian)
+	assert.NoError(t, err)
+	assert.Equal(t, pts[3], iter.IndexPoint(3))
+
+	_, err = m.NewIterOrder(&pointRecord{}, binary.BigEndian)
+	assert.Error(t, err)
+	_, err = little.NewIterOrder(&pointRecord{}, binary.LittleEndian)
+	assert.NoError(t, err)
+
+	pair := make([]byte, 16)
+	binary.BigEndian.PutUint64(pair, math.Float64bits(AlaLat))
+	binary.BigEndian.PutUint64(pair[8:], math.Float64bits(AlaLon))
+	assert.Equal(t, Pair{AlaLat, AlaLon}, DecodePairOrder(pair, binary.BigEndian))
+}
+
+func TestOpenGzip(t *testing.T) {
+	pts := samplePoints()
+	var buf bytes.Buffer
+	gzw := gzip.NewWriter(&buf)
+	if _, err := gzw.Write(encodePoints(pts)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(t.TempDir(), "points.bin.gz")
+	if err := os.WriteFile(filename, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := OpenGzip(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	iter := m.NewIter(&pointRecord{})
+	assert.Equal(t, len(pts), iter.Len())
+
+	pt := GeoPoint(AlaLat+0.051, AlaLon+0.049)
+	idx, dist := Bestest(iter, pt, 1.0)
+	want, wantDist := Bestest(pts, pt, 1.0)
+	assert.Equal(t, want, idx)
+	assert.Equal(t, wantDist, dist)
+	assert.Equal(t, pts[want], iter.IndexPoint(idx))
+
+	temp := m.temp
+	assert.NoError(t, m.Close())
+	_, err = os.Stat(temp)
+	assert.True(t, os.IsNotExist(err))
+}
+
+// namedRecord is a point followed by a variable length name
+type namedRecord struct {
+	pointRecord
+	name string
+}
+
+func (r *namedRecord) Decode(b []byte) error {
+	r.pt = DecodePoint(b)
+	r.name = string(b[8:])
+	return nil
+}
+
+func TestVarIter(t *testing.T) {
+	pts := samplePoints()
+	var data []byte
+	var offsets []int64
+	for i, pt := range pts {
+		offsets = append(offsets, int64(len(data)))
+		data = append(data, encodePoints([]Point{pt})...)
+		data = append(data, strings.Repeat("x", i%7)+strconv.Itoa(i)...)
+	}
+	m := &MFile{B: data}
+	rec := &namedRecord{}
+	iter := m.NewVarIter(rec, offsets)
+	assert.Equal(t, len(pts), iter.Len())
+
+	pt := GeoPoint(AlaLat+0.051, AlaLon+0.049)
+	want, wantDist := Bestest(pts, pt, 1.0)
+	idx, dist := Bestest(iter, pt, 1.0)
+	assert.Equal(t, want, idx)
+	assert.Equal(t, wantDist, dist)
+
+	got := iter.Get(idx).(*namedRecord)
+	assert.Equal(t, pts[want], got.Point())
+	assert.Equal(t, strings.Repeat("x", idx%7)+strconv.Itoa(idx), got.name)
+
+	// and the last one runs to the end of the data
+	last := iter.Get(len(pts) - 1).(*namedRecord)
+	assert.Equal(t, pts[len(pts)-1], last.Point())
+	assert.True(t, strings.HasSuffix(last.name, strconv.Itoa(len(pts)-1)))
+}
+
+func TestNearestIterator(t *testing.T) {
+	pts := samplePoints()
+	m := &MFile{B: encodePoints(pts)}
+	iter := m.NewIter(&pointRecord{})
+
+	pt := GeoPoint(AlaLat+0.051, AlaLon+0.049)
+	idx, dists := KNearest(pts, pt, 5, 100)
+	next := iter.NearestIterator(pt)
+	for i := range idx {
+		rec, dist, ok := next()
+		assert.True(t, ok)
+		assert.Equal(t, pts[idx[i]], rec.(*pointRecord).Point())
+		assert.Equal(t, dists[i], dist)
+	}
+
+	// keeps going until everything is returned
+	count := 5
+	last := dists[4]
+	for {
+		_, dist, ok := next()
+		if !ok {
+			break
+		}
+		assert.GreaterOrEqual(t, dist, last)
+		last = dist
+		count++
+	}
+	assert.Equal(t, len(pts), count)
+}
+
+func TestPointCopy(t *testing.T) {
+	pts := samplePoints()
+	m := &MFile{B: encodePoints(pts)}
+	iter := m.NewIter(&pointRecord{})
+
+	first := iter.Get(1)
+	second := iter.Get(2)
+	// the same decoder, so the first now holds the second record
+	assert.Same(t, first, second)
+	assert.Equal(t, pts[2], first.(*pointRecord).Point())
+
+	pt1 := iter.PointCopy(1)
+	pt2 := iter.PointCopy(2)
+	assert.Equal(t, pts[1], pt1)
+	assert.Equal(t, pts[2], pt2)
+	assert.NotEqual(t, pt1, pt2)
+}
+
+func TestNearestInBox(t *testing.T) {
+	pts := samplePoints()
+	m := &MFile{B: encodePoints(pts)}
+	iter := m.NewIter(&pointRecord{})
+
+	pt := GeoPoint(AlaLat+0.051, AlaLon+0.049)
+	want, wantDist := Bestest(iter, pt, 1.0)
+	rec, dist, err := iter.NearestInBox(pt.BoundingBox(2.0), pt)
+	assert.NoError(t, err)
+	assert.Equal(t, pts[want], rec.(*pointRecord).Point())
+	assert.Equal(t, wantDist, dist)
+
+	// nothing is there
+	_, _, err = iter.NearestInBox(GeoPoint(0, 0).BoundingBox(2.0), pt)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, _, err = iter.NearestInBox(Rect{{AlaLat + 0.001, AlaLon - 1}, {AlaLat + 0.002, AlaLon + 1}}, pt)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// extends past the end of the data
+	box := Rect{{AlaLat + 0.185, AlaLon + 0.185}, {AlaLat + 1, AlaLon + 1}}
+	rec, _, err = iter.NearestInBox(box, pt)
+	assert.NoError(t, err)
+	assert.Equal(t, GeoPoint(AlaLat+0.19, AlaLon+0.19), rec.(*pointRecord).Point())
+}
+
+func TestRangerFilter(t *testing.T) {
+	pts := samplePoints()
+	m := &MFile{B: encodePoints(pts)}
+	iter := m.NewIter(&pointRecord{})
+
+	from, to := GeoPoint(AlaLat+0.025, AlaLon+0.025), GeoPoint(AlaLat+0.105, AlaLon+0.105)
+	var all []Point
+	err := iter.Ranger(from, to, func(rec interface{}) {
+		all = append(all, rec.(*pointRecord).Point())
+	}, nil)
+	assert.NoError(t, err)
+	assert.Len(t, all, 64)
+
+	// only every other column
+	even := func(rec interface{}) bool {
+		col := math.Round(float64(rec.(*pointRecord).Point().Lon-AlaLon) / 0.01)
+		return int(col)%2 == 0
+	}
+	var kept []Point
+	err = iter.RangerFilter(from, to, func(rec interface{}) {
+		assert.True(t, even(rec))
+		kept = append(kept, rec.(*pointRecord).Point())
+	}, nil, even)
+	assert.NoError(t, err)
+	assert.Len(t, kept, len(all)/2)
+	for _, pt := range kept {
+		assert.Contains(t, all, pt)
+	}
+}
+
+func TestRangerLimit(t *testing.T) {
+	pts := samplePoints()
+	m := &MFile{B: encodePoints(pts)}
+	iter := m.NewIter(&pointRecord{})
+
+	from, to := GeoPoint(AlaLat+0.025, AlaLon+0.025), GeoPoint(AlaLat+0.105, AlaLon+0.105)
+	var all []Point
+	err := iter.Ranger(from, to, func(rec interface{}) {
+		all = append(all, rec.(*pointRecord).Point())
+	}, nil)
+	assert.NoError(t, err)
+
+	for _, limit := range []int{1, 10, len(all), len(all) + 1, 0, -1} {
+		var got []Point
+		n, err := iter.RangerLimit(from, to, limit, func(rec interface{}) {
+			got = append(got, rec.(*pointRecord).Point())
+		}, nil)
+		assert.NoError(t, err)
+		want := all
+		if limit > 0 && limit < len(all) {
+			want = all[:limit]
+		}
+		assert.Equal(t, len(want), n, "limit %d", limit)
+		assert.Equal(t, want, got, "limit %d", limit)
+	}
+}
+
+func TestInPolygon(t *testing.T) {
+	pts := samplePoints()
+	m := &MFile{B: encodePoints(pts)}
+	iter := m.NewIter(&pointRecord{})
+
+	// the vertices sit between grid points so none are on an edge
+	corner := func(row, col float64) Point {
+		return GeoPoint(AlaLat+row*0.01, AlaLon+col*0.01)
+	}
+	tri := Polygon{corner(1.5, 1.5), corner(1.5, 14.5), corner(14.5, 1.5), corner(1.5, 1.5)}
+
+	var want []Point
+	for _, pt := range pts {
+		if tri.ContainsPoint(pt) {
+			want = append(want, pt)
+		}
+	}
+
+	var got []Point
+	err := iter.InPolygon(tri, func(rec interface{}) {
+		got = append(got, rec.(*pointRecord).Point())
+	})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, got)
+	assert.ElementsMatch(t, want, got)
+
+	// the far corner of the bounding box is outside the triangle
+	assert.NotContains(t, got, corner(14, 14))
+	assert.Contains(t, got, corner(2, 2))
+}
+
+func TestNearestBytes(t *testing.T) {
+	pts := samplePoints()
+	m := &MFile{B: encodePoints(pts)}
+	iter := m.NewIter(&pointRecord{})
+
+	pt := GeoPoint(AlaLat+0.051, AlaLon+0.049)
+	idx, want := Bestest(pts, pt, 1)
+	b, dist, err := iter.NearestBytes(pt, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, iter.Bytes(idx), b)
+	assert.Equal(t, pts[idx], DecodePoint(b))
+	assert.InDelta(t, want, dist, 0.000001)
+
+	_, dist, err = iter.NearestBytes(GeoPoint(0, 0), 1)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, -1.0, dist)
+}
+
+func TestCountDuplicates(t *testing.T) {
+	pts := samplePoints()
+	m := &MFile{B: encodePoints(pts)}
+	assert.Equal(t, 0, m.NewIter(&pointRecord{}).CountDuplicates())
+
+	// one point twice more, and another once more
+	pts = append(pts, pts[10], pts[10], pts[200])
+	sort.Sort(pts)
+	m = &MFile{B: encodePoints(pts)}
+	assert.Equal(t, 3, m.NewIter(&pointRecord{}).CountDuplicates())
+
+	m = &MFile{}
+	assert.Equal(t, 0, m.NewIter(&pointRecord{}).CountDuplicates())
+}
+
+func TestRangerStride(t *testing.T) {
+	pts := samplePoints()
+	m := &MFile{B: encodePoints(pts)}
+	iter := m.NewIter(&pointRecord{})
+
+	// the 10 points in a single row of the grid
+	from, to := GeoPoint(AlaLat+0.045, AlaLon+0.005), GeoPoint(AlaLat+0.055, AlaLon+0.105)
+	var all []Point
+	err := iter.Ranger(from, to, func(rec interface{}) {
+		all = append(all, rec.(*pointRecord).Point())
+	}, nil)
+	assert.NoError(t, err)
+	assert.Len(t, all, 10)
+
+	var got []Point
+	err = iter.RangerStride(from, to, 3, func(rec interface{}) {
+		got = append(got, rec.(*pointRecord).Point())
+	}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []Point{all[0], all[3], all[6], all[9]}, got)
+
+	got = nil
+	err = iter.RangerStride(from, to, 1, func(rec interface{}) {
+		got = append(got, rec.(*pointRecord).Point())
+	}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, all, got)
+
+	assert.Error(t, iter.RangerStride(from, to, 0, func(interface{}) {}, nil))
+}
+
+func TestGeohashIndex(t *testing.T) {
+	pts := samplePoints()
+	m := &MFile{B: encodePoints(pts)}
+	iter := m.NewIter(&pointRecord{})
+
+	index := iter.GeohashIndex(5)
+	assert.Greater(t, len(index), 1)
+	assert.Less(t, len(index), len(pts))
+	var count int
+	for hash, indices := range index {
+		for _, i := range indices {
+			assert.Equal(t, hash, pts[i].Geohash(5))
+		}
+		count += len(indices)
+	}
+	assert.Equal(t, len(pts), count)
+
+	// neighbors in the grid (about 1km apart) share a cell
+	a, b := pts[0], pts[20]
+	assert.InDelta(t, 0.01, float64(b.Lat-a.Lat), 0.0001)
+	assert.Equal(t, a.Geohash(5), b.Geohash(5))
+	assert.Contains(t, index[a.Geohash(5)], 0)
+	assert.Contains(t, index[a.Geohash(5)], 20)
+}
+
+// failWriter fails every write
+type failWriter struct{}
+
+func (failWriter) Write([]byte) (int, error) {
+	return 0, os.ErrClosed
+}
+
+func TestRangerNDJSON(t *testing.T) {
+	pts := samplePoints()
+	m := &MFile{B: encodePoints(pts)}
+	iter := m.NewIter(&pointRecord{})
+
+	from, to := GeoPoint(AlaLat+0.025, AlaLon+0.025), GeoPoint(AlaLat+0.105, AlaLon+0.105)
+	var all []Point
+	err := iter.Ranger(from, to, func(rec interface{}) {
+		all = append(all, rec.(*pointRecord).Point())
+	}, nil)
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, iter.RangerNDJSON(&buf, from, to, nil))
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	assert.Len(t, lines, len(all))
+	for i, line := range lines {
+		var pt Point
+		assert.NoError(t, json.Unmarshal([]byte(line), &pt), line)
+		assert.InDelta(t, float64(all[i].Lat), float64(pt.Lat), 0.00001)
+		assert.InDelta(t, float64(all[i].Lon), float64(pt.Lon), 0.00001)
+	}
+
+	assert.ErrorIs(t, iter.RangerNDJSON(failWriter{}, from, to, nil), os.ErrClosed)
+}
@@ -0,0 +1,123 @@
+package geo
+
+import (
+	"math"
+	"sort"
+)
+
+// planar is a point projected onto a local flat plane (in Km)
+type planar struct {
+	x, y float64
+	idx  int
+}
+
+func planarDist(a, b planar) float64 {
+	return math.Hypot(a.x-b.x, a.y-b.y)
+}
+
+// project returns the points on a plane centered on their mean latitude,
+// which is only reasonable for points that are relatively close together
+func project(pts []Point) []planar {
+	var sum float64
+	for _, pt := range pts {
+		sum += float64(pt.Lat)
+	}
+	lonKm := LonKilos(sum / float64(len(pts)))
+	flat := make([]planar, len(pts))
+	for i, pt := range pts {
+		flat[i] = planar{
+			x:   float64(pt.Lon) * lonKm,
+			y:   float64(pt.Lat) * DegreeToKilometer,
+			idx: i,
+		}
+	}
+	return flat
+}
+
+// ClosestPair returns the indexes of the two points closest to each other,
+// and the distance between them.
+// It returns -1, -1 (and -1 distance) if there are fewer than two points.
+//
+// The search is done on a local planar projection (so it is best suited
+// to regional data) with the final distance calculated using Distance.
+func ClosestPair(pts []Point) (i, j int, dist float64) {
+	if len(pts) < 2 {
+		return -1, -1, -1
+	}
+	flat := project(pts)
+	sort.Slice(flat, func(a, b int) bool {
+		return flat[a].x < flat[b].x
+	})
+	a, b, _ := closestPair(flat)
+	i, j = a.idx, b.idx
+	if i > j {
+		i, j = j, i
+	}
+	return i, j, pts[i].Distance(pts[j])
+}
+
+// closestPair is the classic divide and conquer search,
+// with flat being sorted by x
+func closestPair(flat []planar) (planar, planar, float64) {
+	if len(flat) <= 3 {
+		var a, b planar
+		best := math.Inf(1)
+		for i := 0; i < len(flat); i++ {
+			for j := i + 1; j < len(flat); j++ {
+				if d := planarDist(flat[i], flat[j]); d < best {
+					a, b, best = flat[i], flat[j], d
+				}
+			}
+		}
+		return a, b, best
+	}
+
+	mid := len(flat) / 2
+	midX := flat[mid].x
+	a, b, best := closestPair(flat[:mid])
+	if c, d, dist := closestPair(flat[mid:]); dist < best {
+		a, b, best = c, d, dist
+	}
+
+	// check for any closer pairs that straddle the dividing line
+	var strip []planar
+	for _, p := range flat {
+		if math.Abs(p.x-midX) < best {
+			strip = append(strip, p)
+		}
+	}
+	sort.Slice(strip, func(i, j int) bool {
+		return strip[i].y < strip[j].y
+	})
+	for i := range strip {
+		for j := i + 1; j < len(strip) && strip[j].y-strip[i].y < best; j++ {
+			if d := planarDist(strip[i], strip[j]); d < best {
+				a, b, best = strip[i], strip[j], d
+			}
+		}
+	}
+	return a, b, best
+}
+
+// NearestJoin returns, for each point in a, the index of the closest point in b
+// that is within maxKm, or -1 if there isn't one.
+// If several are the same distance away, the lowest index is used.
+//
+// The points in b are indexed in a Grid, so only nearby points are compared
+func NearestJoin(a, b []Point, maxKm float64) []int {
+	matches := make([]int, len(a))
+	if !(maxKm > 0) {
+		for i := range matches {
+			matches[i] = -1
+		}
+		return matches
+	}
+	grid := NewGrid(maxKm / DegreeToKilometer)
+	for i, pt := range b {
+		grid.Insert(i, pt)
+	}
+	for i, pt := range a {
+		matches[i], _ = grid.Nearest(pt, maxKm)
+	}
+	return matches
+}
@@ -0,0 +1,132 @@
+package geo
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClosestPair(t *testing.T) {
+	i, j, dist := ClosestPair(nil)
+	assert.Equal(t, -1, i)
+	assert.Equal(t, -1, j)
+	assert.Equal(t, -1.0, dist)
+	i, j, _ = ClosestPair([]Point{GeoPoint(AlaLat, AlaLon)})
+	assert.Equal(t, -1, i)
+	assert.Equal(t, -1, j)
+
+	pts := []Point{
+		GeoPoint(SFLat, SFLon),
+		GeoPoint(ZepLat, ZepLon),
+		GeoPoint(AlaLat, AlaLon),
+		GeoPoint(PortLat, PortLon),
+		GeoPoint(AlaLat+0.001, AlaLon+0.001),
+		GeoPoint(HouLat, HouLon),
+	}
+	i, j, dist = ClosestPair(pts)
+	assert.Equal(t, 2, i)
+	assert.Equal(t, 4, j)
+	assert.Equal(t, pts[2].Distance(pts[4]), dist)
+}
+
+func bruteClosestPair(pts []Point) (int, int, float64) {
+	bi, bj, best := -1, -1, -1.0
+	for i := range pts {
+		for j := i + 1; j < len(pts); j++ {
+			if d := pts[i].Distance(pts[j]); best < 0 || d < best {
+				bi, bj, best = i, j, d
+			}
+		}
+	}
+	return bi, bj, best
+}
+
+func TestClosestPairRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(7))
+	for n := 2; n < 300; n += 17 {
+		pts := make([]Point, n)
+		for i := range pts {
+			pts[i] = GeoPoint(AlaLat+r.Float64(), AlaLon+r.Float64())
+		}
+		_, _, want := bruteClosestPair(pts)
+		i, j, dist := ClosestPair(pts)
+		assert.Less(t, i, j)
+		assert.InDelta(t, want, dist, want*0.001+1e-6, "n=%d", n)
+	}
+}
+
+func bruteJoin(a, b []Point, maxKm float64) []int {
+	matches := make([]int, len(a))
+	for i, pt := range a {
+		matches[i] = -1
+		best := maxKm
+		for j, x := range b {
+			if d := pt.Distance(x); d <= maxKm && (matches[i] < 0 || d < best) {
+				matches[i], best = j, d
+			}
+		}
+	}
+	return matches
+}
+
+func randomPoints(r *rand.Rand, n int, spread float64) []Point {
+	pts := make([]Point, n)
+	for i := range pts {
+		pts[i] = GeoPoint(AlaLat+r.Float64()*spread, AlaLon+r.Float64()*spread)
+	}
+	return pts
+}
+
+func TestNearestJoin(t *testing.T) {
+	deliveries := []Point{
+		GeoPoint(AlaLat+0.001, AlaLon),
+		GeoPoint(SFLat, SFLon+0.01),
+		GeoPoint(HouLat, HouLon),
+		GeoPoint(ZepLat-0.02, ZepLon),
+	}
+	depots := []Point{
+		GeoPoint(ZepLat, ZepLon),
+		GeoPoint(SFLat, SFLon),
+		GeoPoint(AlaLat, AlaLon),
+	}
+	assert.Equal(t, []int{2, 1, -1, 0}, NearestJoin(deliveries, depots, 5))
+	assert.Equal(t, []int{2, 1, -1, -1}, NearestJoin(deliveries, depots, 1))
+	assert.Equal(t, []int{-1, -1, -1, -1}, NearestJoin(deliveries, depots, 0))
+	assert.Equal(t, []int{-1, -1, -1, -1}, NearestJoin(deliveries, depots, math.NaN()))
+
+	// exact matches, with duplicates going to the first of them
+	dupes := append(depots, depots...)
+	assert.Equal(t, []int{0, 1, 2}, NearestJoin(depots, dupes, 1))
+	reversed := []Point{depots[2], depots[1], depots[0], depots[2], depots[1], depots[0]}
+	assert.Equal(t, []int{2, 1, 0}, NearestJoin(depots, reversed, 1))
+
+	// at the pole, and across the antimeridian
+	polar := []Point{GeoPoint(90, 0), GeoPoint(-89.999, 10), GeoPoint(10, 180)}
+	stations := []Point{GeoPoint(10, -179.999), GeoPoint(89.995, 120), GeoPoint(-89.995, -170)}
+	assert.Equal(t, []int{1, 2, 0}, NearestJoin(polar, stations, 1))
+	assert.Equal(t, bruteJoin(polar, stations, 1), NearestJoin(polar, stations, 1))
+
+	r := rand.New(rand.NewSource(3))
+	a, b := randomPoints(r, 200, 1), randomPoints(r, 100, 1)
+	assert.Equal(t, bruteJoin(a, b, 5), NearestJoin(a, b, 5))
+}
+
+func BenchmarkNearestJoin(b *testing.B) {
+	r := rand.New(rand.NewSource(3))
+	deliveries, depots := randomPoints(r, 2000, 1), randomPoints(r, 1000, 1)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		NearestJoin(deliveries, depots, 2)
+	}
+}
+
+func BenchmarkNearestJoinBrute(b *testing.B) {
+	r := rand.New(rand.NewSource(3))
+	deliveries, depots := randomPoints(r, 2000, 1), randomPoints(r, 1000, 1)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bruteJoin(deliveries, depots, 2)
+	}
+}
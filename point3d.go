@@ -0,0 +1,54 @@
+package geo
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Point3D is a point with an altitude (in meters)
+type Point3D struct {
+	Point
+	Alt GeoType
+}
+
+// Query3D parses a "lat,lon,altitude" (or "lat/lon/altitude") string,
+// with the altitude in meters
+func Query3D(s string) (Point3D, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 3 {
+		parts = strings.Split(s, "/")
+		if len(parts) != 3 {
+			return Point3D{}, ErrInvalidCoordinates
+		}
+	}
+	pt, err := QueryPoint(parts[0] + "," + parts[1])
+	if err != nil {
+		return Point3D{}, err
+	}
+	alt, err := strconv.ParseFloat(strings.TrimSpace(parts[2]), 32)
+	if err != nil {
+		return Point3D{}, fmt.Errorf("invalid altitude %q -- %w", parts[2], ErrInvalidCoordinates)
+	}
+	return Point3D{pt, GeoType(alt)}, nil
+}
+
+// cartesian returns the earth centered coordinates of the point in meters
+func (p Point3D) cartesian() (x, y, z float64) {
+	r := EarthRadiusInKM*1000 + float64(p.Alt)
+	v := toVector(p.Point)
+	return v[0] * r, v[1] * r, v[2] * r
+}
+
+// DistanceMeters returns the straight line (chord) distance in meters
+// between the two points, including the difference in altitude.
+//
+// For points at the same altitude this is slightly less than
+// the great circle distance, by about 0.7km over 1000km,
+// but for shorter distances they are effectively the same.
+func (p Point3D) DistanceMeters(x Point3D) float64 {
+	x1, y1, z1 := p.cartesian()
+	x2, y2, z2 := x.cartesian()
+	return math.Sqrt((x2-x1)*(x2-x1) + (y2-y1)*(y2-y1) + (z2-z1)*(z2-z1))
+}
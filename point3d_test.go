@@ -0,0 +1,43 @@
+package geo
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuery3D(t *testing.T) {
+	pt, err := Query3D("37.7703358,-122.2569864,12.5")
+	assert.NoError(t, err)
+	assert.Equal(t, Point3D{GeoPoint(AlaLat, AlaLon), 12.5}, pt)
+
+	pt, err = Query3D("37.7703358/-122.2569864/-3")
+	assert.NoError(t, err)
+	assert.Equal(t, GeoType(-3), pt.Alt)
+
+	for _, s := range []string{"37.77,-122.25", "37.77,-122.25,high", "x,-122.25,10"} {
+		_, err := Query3D(s)
+		assert.ErrorIs(t, err, ErrInvalidCoordinates, s)
+	}
+}
+
+func TestDistanceMeters(t *testing.T) {
+	sf := GeoPoint(SFLat, SFLon)
+	ala := GeoPoint(AlaLat, AlaLon)
+	flat := sf.Distance(ala) * 1000
+
+	// same altitude is the same as 2D
+	for _, alt := range []GeoType{0, 100} {
+		dist := Point3D{sf, alt}.DistanceMeters(Point3D{ala, alt})
+		assert.InDelta(t, flat, dist, 1.0, "altitude: %f", alt)
+	}
+
+	// straight up
+	assert.InDelta(t, 1000.0, Point3D{sf, 0}.DistanceMeters(Point3D{sf, 1000}), 0.001)
+
+	// climbing adds to the distance
+	dist := Point3D{sf, 0}.DistanceMeters(Point3D{ala, 5000})
+	assert.Greater(t, dist, flat)
+	assert.InDelta(t, math.Hypot(flat, 5000), dist, 5.0)
+}
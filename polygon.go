@@ -0,0 +1,104 @@
+package geo
+
+import (
+	"math"
+)
+
+// Polygon is a closed ring of points, i.e., the last point repeats the first
+type Polygon []Point
+
+// Circle is a Container for the points within RadiusKm of Center
+type Circle struct {
+	Center   Point
+	RadiusKm float64
+}
+
+// ContainsPoint returns true if the point is within the circle.
+// Invalid points (e.g., NaN) are never inside
+func (c Circle) ContainsPoint(pt Point) bool {
+	return pt.Valid() && c.Center.Distance(pt) <= c.RadiusKm
+}
+
+// BufferPolygon returns a polygon of the given number of segments
+// approximating the circle of radiusKm around p (see Ring),
+// or nil if there are fewer than 3 segments.
+// As the edges are chords, it is slightly inside the true circle
+func (p Point) BufferPolygon(radiusKm float64, segments int) Polygon {
+	return Polygon(Ring(p, radiusKm, segments))
+}
+
+// Len returns the number of points in the polygon (to satisfy GeoPoints)
+func (poly Polygon) Len() int {
+	return len(poly)
+}
+
+// IndexPoint returns the point at the given index (to satisfy GeoPoints)
+func (poly Polygon) IndexPoint(i int) Point {
+	return poly[i]
+}
+
+// ContainsPoint returns true if the point is inside the polygon.
+// It treats lat/lon as planar coordinates (ray casting),
+// so edges are not the true great circle paths between vertices,
+// and points exactly on an edge may fall either way.
+func (poly Polygon) ContainsPoint(pt Point) bool {
+	inside := false
+	for i := 1; i < len(poly); i++ {
+		a, b := poly[i-1], poly[i]
+		if (a.Lat > pt.Lat) == (b.Lat > pt.Lat) {
+			continue
+		}
+		// the lon where this edge crosses the latitude of our point
+		lon := float64(a.Lon) + float64(pt.Lat-a.Lat)/float64(b.Lat-a.Lat)*float64(b.Lon-a.Lon)
+		if float64(pt.Lon) < lon {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// AreaKm returns the area of the polygon in square kilometers,
+// using the spherical excess of its edges.
+// It is good for small to moderately sized polygons.
+func (poly Polygon) AreaKm() float64 {
+	var total float64
+	for i := 1; i < len(poly); i++ {
+		a, b := poly[i-1], poly[i]
+		lon1, lon2 := deg2rad(float64(a.Lon)), deg2rad(float64(b.Lon))
+		lat1, lat2 := deg2rad(float64(a.Lat)), deg2rad(float64(b.Lat))
+		total += (lon2 - lon1) * (2 + math.Sin(lat1) + math.Sin(lat2))
+	}
+	return math.Abs(total * EarthRadiusInKM * EarthRadiusInKM / 2)
+}
+
+// authalic returns the sine of the authalic latitude
+// (i.e., the latitude on a sphere of equal area) for the WGS84 latitude
+func authalic(latRad float64) float64 {
+	e2 := wgs84F * (2 - wgs84F)
+	e := math.Sqrt(e2)
+	q := func(sin float64) float64 {
+		return (1 - e2) * (sin/(1-e2*sin*sin) - math.Log((1-e*sin)/(1+e*sin))/(2*e))
+	}
+	return q(math.Sin(latRad)) / q(1)
+}
+
+// SignedAreaKm returns the area of the polygon in square kilometers
+// on the WGS84 ellipsoid, which is positive if the points wind
+// counterclockwise and negative if clockwise.
+//
+// It integrates along the edges (Green's theorem) using authalic latitudes,
+// so unlike AreaKm it accounts for the flattening of the earth.
+func (poly Polygon) SignedAreaKm() float64 {
+	e2 := wgs84F * (2 - wgs84F)
+	e := math.Sqrt(e2)
+	qp := 1 - (1-e2)/(2*e)*math.Log((1-e)/(1+e))
+	radius := wgs84A / 1000 * math.Sqrt(qp/2) // authalic radius in km
+
+	var total float64
+	for i := 1; i < len(poly); i++ {
+		a, b := poly[i-1], poly[i]
+		dlon := wrapRad(deg2rad(float64(b.Lon - a.Lon)))
+		total += dlon * (2 + authalic(deg2rad(float64(a.Lat))) + authalic(deg2rad(float64(b.Lat))))
+	}
+	return -total * radius * radius / 2
+}
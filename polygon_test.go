@@ -0,0 +1,73 @@
+package geo
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignedAreaKm(t *testing.T) {
+	ccw := Polygon{
+		GeoPoint(AlaLat, AlaLon),
+		GeoPoint(AlaLat, AlaLon+1),
+		GeoPoint(AlaLat+1, AlaLon+1),
+		GeoPoint(AlaLat+1, AlaLon),
+		GeoPoint(AlaLat, AlaLon),
+	}
+	cw := make(Polygon, len(ccw))
+	for i, pt := range ccw {
+		cw[len(ccw)-1-i] = pt
+	}
+	area := ccw.SignedAreaKm()
+	assert.Greater(t, area, 0.0)
+	assert.Less(t, cw.SignedAreaKm(), 0.0)
+	assert.InDelta(t, area, -cw.SignedAreaKm(), 1e-6)
+	assert.InDelta(t, ccw.AreaKm(), math.Abs(area), ccw.AreaKm()*0.005)
+	t.Logf("ellipsoid: %f sphere: %f", area, ccw.AreaKm())
+
+	// across the antimeridian
+	wrapped := Polygon{
+		GeoPoint(-10, 179.5),
+		GeoPoint(-10, -179.5),
+		GeoPoint(-9, -179.5),
+		GeoPoint(-9, 179.5),
+		GeoPoint(-10, 179.5),
+	}
+	shifted := Polygon{
+		GeoPoint(-10, 0),
+		GeoPoint(-10, 1),
+		GeoPoint(-9, 1),
+		GeoPoint(-9, 0),
+		GeoPoint(-10, 0),
+	}
+	assert.InDelta(t, shifted.SignedAreaKm(), wrapped.SignedAreaKm(), 1e-6)
+}
+
+func TestBufferPolygon(t *testing.T) {
+	center := GeoPoint(AlaLat, AlaLon)
+	const radius = 10.0
+	poly := center.BufferPolygon(radius, 36)
+	assert.Len(t, poly, 37)
+	assert.Equal(t, poly[0], poly[len(poly)-1])
+	circle := Circle{center, radius}
+
+	for _, brng := range []float64{0, 45, 100, 200, 333} {
+		for _, km := range []float64{0.5, 5, 9, 11, 15, 50} {
+			pt := Destination(AlaLat, AlaLon, km, brng)
+			assert.Equal(t, circle.ContainsPoint(pt), poly.ContainsPoint(pt), "%v km at %v", km, brng)
+		}
+	}
+	assert.True(t, poly.ContainsPoint(center))
+	assert.True(t, circle.ContainsPoint(center))
+	nan := math.NaN()
+	assert.False(t, circle.ContainsPoint(GeoPoint(nan, nan)))
+	assert.False(t, Circle{GeoPoint(nan, nan), radius}.ContainsPoint(center))
+
+	// the chords make it a bit smaller than the circle
+	area := math.Pi * radius * radius
+	assert.Less(t, poly.AreaKm(), area)
+	assert.InEpsilon(t, area, poly.AreaKm(), 0.01)
+
+	assert.Nil(t, center.BufferPolygon(radius, 2))
+}
@@ -0,0 +1,140 @@
+package geo
+
+import (
+	"math"
+	"sort"
+)
+
+// p2 estimates a single quantile using the P² algorithm
+// (Jain & Chlamtac, 1985), which uses 5 markers rather than
+// keeping every observation
+type p2 struct {
+	p       float64
+	count   int
+	heights [5]float64
+	pos     [5]float64 // actual marker positions
+	want    [5]float64 // desired marker positions
+	incr    [5]float64
+}
+
+func newP2(p float64) *p2 {
+	return &p2{
+		p:    p,
+		pos:  [5]float64{1, 2, 3, 4, 5},
+		want: [5]float64{1, 1 + 2*p, 1 + 4*p, 3 + 2*p, 5},
+		incr: [5]float64{0, p / 2, p, (1 + p) / 2, 1},
+	}
+}
+
+func (e *p2) add(x float64) {
+	if e.count < 5 {
+		e.heights[e.count] = x
+		e.count++
+		if e.count == 5 {
+			sort.Float64s(e.heights[:])
+		}
+		return
+	}
+	e.count++
+
+	var k int
+	switch h := &e.heights; {
+	case x < h[0]:
+		h[0] = x
+		k = 0
+	case x < h[1]:
+		k = 0
+	case x < h[2]:
+		k = 1
+	case x < h[3]:
+		k = 2
+	case x <= h[4]:
+		k = 3
+	default:
+		h[4] = x
+		k = 3
+	}
+	for i := k + 1; i < 5; i++ {
+		e.pos[i]++
+	}
+	for i := range e.want {
+		e.want[i] += e.incr[i]
+	}
+
+	// adjust the middle markers if they've drifted
+	for i := 1; i < 4; i++ {
+		d := e.want[i] - e.pos[i]
+		if (d >= 1 && e.pos[i+1]-e.pos[i] > 1) || (d <= -1 && e.pos[i-1]-e.pos[i] < -1) {
+			d = math.Copysign(1, d)
+			h := e.parabolic(i, d)
+			if h <= e.heights[i-1] || h >= e.heights[i+1] {
+				h = e.linear(i, d)
+			}
+			e.heights[i] = h
+			e.pos[i] += d
+		}
+	}
+}
+
+func (e *p2) parabolic(i int, d float64) float64 {
+	q, n := &e.heights, &e.pos
+	return q[i] + d/(n[i+1]-n[i-1])*
+		((n[i]-n[i-1]+d)*(q[i+1]-q[i])/(n[i+1]-n[i])+
+			(n[i+1]-n[i]-d)*(q[i]-q[i-1])/(n[i]-n[i-1]))
+}
+
+func (e *p2) linear(i int, d float64) float64 {
+	j := i + int(d)
+	return e.heights[i] + d*(e.heights[j]-e.heights[i])/(e.pos[j]-e.pos[i])
+}
+
+func (e *p2) estimate() float64 {
+	if e.count == 0 {
+		return math.NaN()
+	}
+	if e.count < 5 {
+		// too few to estimate, so use the real thing
+		sorted := make([]float64, e.count)
+		copy(sorted, e.heights[:e.count])
+		sort.Float64s(sorted)
+		return sorted[int(math.Round(e.p*float64(e.count-1)))]
+	}
+	return e.heights[2]
+}
+
+// DistanceQuantile tracks quantiles of a stream of distances
+// in constant memory, i.e., without storing the distances.
+//
+// The quantiles to track must be given up front,
+// as each is estimated independently.
+type DistanceQuantile struct {
+	estimators map[float64]*p2
+}
+
+// NewDistanceQuantile returns a DistanceQuantile tracking the given quantiles
+// (e.g., 0.5 and 0.9 for the p50 and p90 distances)
+func NewDistanceQuantile(quantiles ...float64) *DistanceQuantile {
+	dq := &DistanceQuantile{estimators: make(map[float64]*p2)}
+	for _, q := range quantiles {
+		dq.estimators[q] = newP2(q)
+	}
+	return dq
+}
+
+// Add records a distance
+func (dq *DistanceQuantile) Add(dist float64) {
+	for _, e := range dq.estimators {
+		e.add(dist)
+	}
+}
+
+// Quantile returns the estimated distance for the quantile,
+// which must be one given to NewDistanceQuantile.
+// It returns NaN for untracked quantiles or if nothing has been added.
+func (dq *DistanceQuantile) Quantile(q float64) float64 {
+	e, ok := dq.estimators[q]
+	if !ok {
+		return math.NaN()
+	}
+	return e.estimate()
+}
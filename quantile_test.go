@@ -0,0 +1,40 @@
+package geo
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistanceQuantile(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+	center := GeoPoint(AlaLat, AlaLon)
+	dq := NewDistanceQuantile(0.5, 0.9, 0.99)
+	var dists []float64
+	for i := 0; i < 20000; i++ {
+		pt := GeoPoint(AlaLat+r.NormFloat64(), AlaLon+r.NormFloat64())
+		dist := center.Distance(pt)
+		dq.Add(dist)
+		dists = append(dists, dist)
+	}
+	sort.Float64s(dists)
+	for _, q := range []float64{0.5, 0.9, 0.99} {
+		exact := dists[int(q*float64(len(dists)-1))]
+		got := dq.Quantile(q)
+		t.Logf("p%.0f: exact:%f estimate:%f", q*100, exact, got)
+		assert.InDelta(t, exact, got, exact*0.02)
+	}
+	assert.True(t, math.IsNaN(dq.Quantile(0.75)))
+}
+
+func TestDistanceQuantileFew(t *testing.T) {
+	dq := NewDistanceQuantile(0.5)
+	assert.True(t, math.IsNaN(dq.Quantile(0.5)))
+	for _, d := range []float64{3, 1, 2} {
+		dq.Add(d)
+	}
+	assert.Equal(t, 2.0, dq.Quantile(0.5))
+}
@@ -0,0 +1,230 @@
+package geo
+
+import (
+	"math"
+)
+
+// AreaKm returns the area of the rect in square kilometers
+func (r Rect) AreaKm() float64 {
+	return AreaInKm(r[0][0], r[0][1], r[1][0], r[1][1])
+}
+
+// AreaMiles returns the area of the rect in square miles
+func (r Rect) AreaMiles() float64 {
+	return AreaInMiles(r[0][0], r[0][1], r[1][0], r[1][1])
+}
+
+// ContainsPoint returns true if the point is within the rect (inclusive)
+func (r Rect) ContainsPoint(pt Point) bool {
+	lat, lon := float64(pt.Lat), float64(pt.Lon)
+	return r[0][0] <= lat && lat <= r[1][0] && r[0][1] <= lon && lon <= r[1][1]
+}
+
+// ContainsPointHalfOpen returns true if the point is within [min, max)
+// on each axis, so a point on an edge shared by the rects of a Tile
+// is contained by exactly one of them.
+// Note that points on the max edges of the tiled area itself are excluded
+func (r Rect) ContainsPointHalfOpen(pt Point) bool {
+	lat, lon := float64(pt.Lat), float64(pt.Lon)
+	return r[0][0] <= lat && lat < r[1][0] && r[0][1] <= lon && lon < r[1][1]
+}
+
+// RectFromCenter returns the rect extending halfWidthKm east and west
+// and halfHeightKm north and south of the center, e.g., for a viewport.
+// The width is measured at the latitude of the center
+func RectFromCenter(center Point, halfWidthKm, halfHeightKm float64) Rect {
+	lat, lon := float64(center.Lat), float64(center.Lon)
+	deltaLat := halfHeightKm / DegreeToKilometer
+	deltaLon := LongitudeKilometerDegrees(lat, halfWidthKm)
+	return Rect{
+		{lat - deltaLat, lon - deltaLon},
+		{lat + deltaLat, lon + deltaLon},
+	}
+}
+
+// ClampToRect returns the point within the rect that is nearest
+// to p in degrees, i.e., p itself if the rect contains it
+func (p Point) ClampToRect(r Rect) Point {
+	lat := math.Max(r[0][0], math.Min(r[1][0], float64(p.Lat)))
+	lon := math.Max(r[0][1], math.Min(r[1][1], float64(p.Lon)))
+	return GeoPoint(lat, lon)
+}
+
+// Center returns the point midway between the corners of the rect
+func (r Rect) Center() Point {
+	return GeoPoint((r[0][0]+r[1][0])/2, (r[0][1]+r[1][1])/2)
+}
+
+// EnclosingCircle returns the center of the rect and the distance (in Km)
+// from it to the furthest corner, so the circle covers the whole rect
+func (r Rect) EnclosingCircle() (Point, float64) {
+	center := r.Center()
+	var radius float64
+	for _, corner := range r.ToPolygon()[:4] {
+		radius = math.Max(radius, center.Distance(corner))
+	}
+	return center, radius
+}
+
+// ToPolygon returns the corners of the rect as a closed ring
+func (r Rect) ToPolygon() Polygon {
+	return Polygon{
+		GeoPoint(r[0][0], r[0][1]),
+		GeoPoint(r[0][0], r[1][1]),
+		GeoPoint(r[1][0], r[1][1]),
+		GeoPoint(r[1][0], r[0][1]),
+		GeoPoint(r[0][0], r[0][1]),
+	}
+}
+
+// Tile splits the rect into rows (of latitude) by cols (of longitude)
+// sub-rects, returned in row-major order starting from the min corner.
+// Neighboring tiles share the same edges.
+// It returns nil if rows or cols are less than 1.
+func (r Rect) Tile(rows, cols int) []Rect {
+	if rows < 1 || cols < 1 {
+		return nil
+	}
+	edge := func(min, max float64, i, n int) float64 {
+		if i == n {
+			return max // avoid any rounding on the outer edge
+		}
+		return min + (max-min)*float64(i)/float64(n)
+	}
+	tiles := make([]Rect, 0, rows*cols)
+	for row := 0; row < rows; row++ {
+		minLat := edge(r[0][0], r[1][0], row, rows)
+		maxLat := edge(r[0][0], r[1][0], row+1, rows)
+		for col := 0; col < cols; col++ {
+			minLon := edge(r[0][1], r[1][1], col, cols)
+			maxLon := edge(r[0][1], r[1][1], col+1, cols)
+			tiles = append(tiles, Rect{{minLat, minLon}, {maxLat, maxLon}})
+		}
+	}
+	return tiles
+}
+
+// MergeIfAligned returns the union of the rects if they share a full edge
+// (within epsDeg), i.e., they are side by side or one above the other
+// and together form a larger rect. Otherwise it returns false,
+// including when they overlap
+func (r Rect) MergeIfAligned(o Rect, epsDeg float64) (Rect, bool) {
+	near := func(a, b float64) bool {
+		return math.Abs(a-b) <= epsDeg
+	}
+	sameLats := near(r[0][0], o[0][0]) && near(r[1][0], o[1][0])
+	sameLons := near(r[0][1], o[0][1]) && near(r[1][1], o[1][1])
+	switch {
+	case sameLats && near(r[1][1], o[0][1]): // o is east of r
+	case sameLats && near(o[1][1], r[0][1]): // o is west of r
+	case sameLons && near(r[1][0], o[0][0]): // o is north of r
+	case sameLons && near(o[1][0], r[0][0]): // o is south of r
+	default:
+		return Rect{}, false
+	}
+	return Rect{
+		{math.Min(r[0][0], o[0][0]), math.Min(r[0][1], o[0][1])},
+		{math.Max(r[1][0], o[1][0]), math.Max(r[1][1], o[1][1])},
+	}, true
+}
+
+// Pan returns the rect moved by a fraction of its own height (north)
+// and width (east), e.g., Pan(0.5, 0) moves it north by half its height.
+// It stops at the poles and the antimeridian rather than going past them,
+// keeping the same size
+func (r Rect) Pan(fracLat, fracLon float64) Rect {
+	dlat := (r[1][0] - r[0][0]) * fracLat
+	dlon := (r[1][1] - r[0][1]) * fracLon
+	dlat = math.Max(-90-r[0][0], math.Min(90-r[1][0], dlat))
+	dlon = math.Max(-180-r[0][1], math.Min(180-r[1][1], dlon))
+	return Rect{
+		{r[0][0] + dlat, r[0][1] + dlon},
+		{r[1][0] + dlat, r[1][1] + dlon},
+	}
+}
+
+// ExpandToInclude returns the smallest rect containing both r and p.
+// The zero value rect is treated as empty, so the result is just p
+// (which means a rect at exactly 0,0 does not count)
+func (r Rect) ExpandToInclude(p Point) Rect {
+	lat, lon := float64(p.Lat), float64(p.Lon)
+	if r == (Rect{}) {
+		return Rect{{lat, lon}, {lat, lon}}
+	}
+	return Rect{
+		{math.Min(r[0][0], lat), math.Min(r[0][1], lon)},
+		{math.Max(r[1][0], lat), math.Max(r[1][1], lon)},
+	}
+}
+
+// Normalize returns the rect with the min corner first and the max corner second,
+// regardless of the order the corners were given in
+func (r Rect) Normalize() Rect {
+	return Rect{
+		{math.Min(r[0][0], r[1][0]), math.Min(r[0][1], r[1][1])},
+		{math.Max(r[0][0], r[1][0]), math.Max(r[0][1], r[1][1])},
+	}
+}
+
+// BoundsOf returns the smallest rect containing all of the points,
+// and false if there are none
+func BoundsOf(g GeoPoints) (Rect, bool) {
+	var b BoundsBuilder
+	for i := 0; i < g.Len(); i++ {
+		b.Add(g.IndexPoint(i))
+	}
+	return b.Rect()
+}
+
+// BoundsBuilder accumulates the bounds of points one at a time,
+// for when they are streamed rather than held in a GeoPoints.
+// The zero value is ready to use
+type BoundsBuilder struct {
+	rect  Rect
+	valid bool
+}
+
+// Add grows the bounds to include the point
+func (b *BoundsBuilder) Add(pt Point) {
+	lat, lon := float64(pt.Lat), float64(pt.Lon)
+	if !b.valid {
+		b.rect = Rect{{lat, lon}, {lat, lon}}
+		b.valid = true
+		return
+	}
+	b.rect[0][0] = math.Min(b.rect[0][0], lat)
+	b.rect[0][1] = math.Min(b.rect[0][1], lon)
+	b.rect[1][0] = math.Max(b.rect[1][0], lat)
+	b.rect[1][1] = math.Max(b.rect[1][1], lon)
+}
+
+// Rect returns the bounds so far, and false if no points have been added
+func (b *BoundsBuilder) Rect() (Rect, bool) {
+	return b.rect, b.valid
+}
+
+// ApproxErrorBound returns the largest difference (in Km) between
+// ApproximateDistanceGeo and Distance found between the corners,
+// edge midpoints, and center of the box, as an estimate
+// of the worst case error of the approximation within it.
+// A box reaching past a pole is sampled only up to it
+func ApproxErrorBound(box Rect) float64 {
+	minLat, minLon, maxLat, maxLon := box[0][0], box[0][1], box[1][0], box[1][1]
+	minLat, maxLat = math.Max(minLat, -90), math.Min(maxLat, 90)
+	midLat, midLon := (minLat+maxLat)/2, (minLon+maxLon)/2
+	var samples []Point
+	for _, lat := range []float64{minLat, midLat, maxLat} {
+		for _, lon := range []float64{minLon, midLon, maxLon} {
+			samples = append(samples, GeoPoint(lat, lon))
+		}
+	}
+	var pairs [][2]Point
+	for i, a := range samples {
+		for _, b := range samples[i+1:] {
+			// both ways, as the approximation is not symmetric
+			pairs = append(pairs, [2]Point{a, b}, [2]Point{b, a})
+		}
+	}
+	maxErr, _ := CompareDistance(pairs)
+	return maxErr
+}
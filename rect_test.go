@@ -0,0 +1,305 @@
+package geo
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRectArea(t *testing.T) {
+	box := Expand(AlaLat, AlaLon, 1.0)
+	assert.Equal(t, AreaInKm(box[0][0], box[0][1], box[1][0], box[1][1]), box.AreaKm())
+	assert.Equal(t, AreaInMiles(box[0][0], box[0][1], box[1][0], box[1][1]), box.AreaMiles())
+
+	box = Rect{{SFLat, SFLon}, {ZepLat, ZepLon}}
+	assert.Equal(t, AreaInKm(SFLat, SFLon, ZepLat, ZepLon), box.AreaKm())
+	assert.Greater(t, box.AreaKm(), box.AreaMiles())
+}
+
+func TestBoundingBox(t *testing.T) {
+	for _, radiusKm := range []float64{0.1, 1, 5, 25} {
+		center := GeoPoint(AlaLat, AlaLon)
+		box := center.BoundingBox(radiusKm)
+		min, max := GeoPoint(box[0][0], box[0][1]), GeoPoint(box[1][0], box[1][1])
+		for _, pt := range Ring(center, radiusKm, 72) {
+			assert.True(t, Within(pt.Lat, pt.Lon, min.Lat, min.Lon, max.Lat, max.Lon),
+				"%v is outside of %v (radius: %f)", pt, box, radiusKm)
+		}
+	}
+}
+
+func TestExpandBox(t *testing.T) {
+	// should be the same box
+	assert.Equal(t, AreaInRange64(Pair{AlaLat, AlaLon}, 1.0), Expand(AlaLat, AlaLon, 1.0))
+}
+
+func TestRectToPolygon(t *testing.T) {
+	box := Rect{{AlaLat, AlaLon}, {AlaLat + 0.1, AlaLon + 0.2}}
+	poly := box.ToPolygon()
+	assert.Len(t, poly, 5)
+	assert.Equal(t, poly[0], poly[len(poly)-1])
+
+	var inside int
+	for lat := AlaLat - 0.0503; lat < AlaLat+0.15; lat += 0.01 {
+		for lon := AlaLon - 0.0503; lon < AlaLon+0.25; lon += 0.01 {
+			pt := GeoPoint(lat, lon)
+			assert.Equal(t, box.ContainsPoint(pt), poly.ContainsPoint(pt), "%v", pt)
+			if box.ContainsPoint(pt) {
+				inside++
+			}
+		}
+	}
+	assert.Greater(t, inside, 0)
+
+	// 0.1 x 0.2 degrees is small enough for the approximations to be close
+	assert.InDelta(t, box.AreaKm(), poly.AreaKm(), box.AreaKm()*0.01)
+}
+
+func TestTile(t *testing.T) {
+	box := Rect{{AlaLat, AlaLon}, {AlaLat + 0.3, AlaLon + 0.7}}
+	assert.Equal(t, []Rect{box}, box.Tile(1, 1))
+	assert.Nil(t, box.Tile(0, 3))
+	assert.Nil(t, box.Tile(3, -1))
+
+	const rows, cols = 3, 7
+	tiles := box.Tile(rows, cols)
+	assert.Len(t, tiles, rows*cols)
+	var area float64
+	for i, tile := range tiles {
+		row, col := i/cols, i%cols
+		area += tile.AreaKm()
+		// no gaps or overlaps with the neighbors
+		if col == 0 {
+			assert.Equal(t, box[0][1], tile[0][1])
+		} else {
+			assert.Equal(t, tiles[i-1][1][1], tile[0][1])
+			assert.Equal(t, tiles[i-1][0][0], tile[0][0])
+		}
+		if col == cols-1 {
+			assert.Equal(t, box[1][1], tile[1][1])
+		}
+		if row == 0 {
+			assert.Equal(t, box[0][0], tile[0][0])
+		} else {
+			assert.Equal(t, tiles[i-cols][1][0], tile[0][0])
+			assert.Equal(t, tiles[i-cols][0][1], tile[0][1])
+		}
+		if row == rows-1 {
+			assert.Equal(t, box[1][0], tile[1][0])
+		}
+	}
+	// the area calc is approximate, so it is not quite additive
+	assert.InDelta(t, box.AreaKm(), area, box.AreaKm()*1e-5)
+}
+
+func TestNormalize(t *testing.T) {
+	const south, west, north, east = SFLat, SFLon, ZepLat, ZepLon
+	want := Rect{{south, west}, {north, east}}
+	for _, box := range []Rect{
+		{{south, west}, {north, east}},
+		{{north, east}, {south, west}},
+		{{north, west}, {south, east}},
+		{{south, east}, {north, west}},
+	} {
+		assert.Equal(t, want, box.Normalize(), "%v", box)
+	}
+}
+
+func TestContainsPointHalfOpen(t *testing.T) {
+	// whole degrees, so points can land exactly on the shared edges
+	box := Rect{{30, -120}, {33, -113}}
+	tiles := box.Tile(3, 7)
+	var doubled int
+	for lat := 30.0; lat < 33; lat += 0.25 {
+		for lon := -120.0; lon < -113; lon += 0.25 {
+			pt := GeoPoint(lat, lon)
+			var open, closed int
+			for _, tile := range tiles {
+				if tile.ContainsPointHalfOpen(pt) {
+					open++
+				}
+				if tile.ContainsPoint(pt) {
+					closed++
+				}
+			}
+			assert.Equal(t, 1, open, "%v", pt)
+			if closed > 1 {
+				doubled++
+			}
+		}
+	}
+	assert.Greater(t, doubled, 0)
+	assert.False(t, box.ContainsPointHalfOpen(GeoPoint(33, -115)))
+	assert.True(t, box.ContainsPoint(GeoPoint(33, -115)))
+}
+
+func TestBoundsBuilder(t *testing.T) {
+	var b BoundsBuilder
+	_, ok := b.Rect()
+	assert.False(t, ok)
+	_, ok = BoundsOf(testPoints{})
+	assert.False(t, ok)
+
+	pts := samplePoints()
+	want, ok := BoundsOf(pts)
+	assert.True(t, ok)
+	for _, pt := range pts {
+		assert.True(t, want.ContainsPoint(pt))
+	}
+	assert.Equal(t, float64(pts[0].Lat), want[0][0])
+	assert.Equal(t, float64(pts[len(pts)-1].Lat), want[1][0])
+
+	for _, pt := range shuffled(pts) {
+		b.Add(pt)
+	}
+	got, ok := b.Rect()
+	assert.True(t, ok)
+	assert.Equal(t, want, got)
+
+	// a single point has no area
+	var one BoundsBuilder
+	one.Add(GeoPoint(AlaLat, AlaLon))
+	got, _ = one.Rect()
+	assert.Equal(t, got[0], got[1])
+}
+
+func TestEnclosingCircle(t *testing.T) {
+	box := GeoPoint(AlaLat, AlaLon).BoundingBox(10)
+	center, radius := box.EnclosingCircle()
+	assert.Equal(t, box.Center(), center)
+	assert.InDelta(t, AlaLat, float64(center.Lat), 1e-5)
+	assert.InDelta(t, AlaLon, float64(center.Lon), 1e-5)
+	// the corners are ~sqrt(2) times the half width away
+	assert.InDelta(t, 10*math.Sqrt2, radius, 0.1)
+	for _, corner := range box.ToPolygon() {
+		assert.LessOrEqual(t, center.Distance(corner), radius)
+	}
+
+	// the corners nearer the equator are further away
+	box = Rect{{50, 0}, {70, 40}}
+	center, radius = box.EnclosingCircle()
+	assert.Equal(t, GeoPoint(60, 20), center)
+	assert.Equal(t, center.Distance(GeoPoint(50, 0)), radius)
+	assert.Greater(t, radius, center.Distance(GeoPoint(70, 40)))
+}
+
+func TestApproxErrorBound(t *testing.T) {
+	var last float64
+	for _, km := range []float64{1, 10, 50, 200} {
+		bound := ApproxErrorBound(GeoPoint(AlaLat, AlaLon).BoundingBox(km))
+		t.Logf("%3.0fkm box at %.0f°: %.4fkm", km, AlaLat, bound)
+		assert.Greater(t, bound, last)
+		last = bound
+	}
+	last = 0
+	for _, lat := range []float64{0, 30, 60, 75} {
+		bound := ApproxErrorBound(GeoPoint(lat, 0).BoundingBox(50))
+		t.Logf("50km box at %2.0f°: %.4fkm", lat, bound)
+		assert.Greater(t, bound, last)
+		last = bound
+	}
+	// the same in either hemisphere
+	assert.Equal(t, ApproxErrorBound(GeoPoint(60, 0).BoundingBox(50)), ApproxErrorBound(GeoPoint(-60, 0).BoundingBox(50)))
+
+	// boxes at and past the poles
+	assert.NotPanics(t, func() { ApproxErrorBound(GeoPoint(89.95, 0).BoundingBox(20)) })
+	assert.NotPanics(t, func() { ApproxErrorBound(GeoPoint(-89.95, 0).BoundingBox(20)) })
+	bound := ApproxErrorBound(Rect{{80, -180}, {90, 180}})
+	assert.False(t, math.IsNaN(bound))
+	assert.Greater(t, bound, 0.0)
+}
+
+func TestMergeIfAligned(t *testing.T) {
+	box := Rect{{10, 20}, {11, 22}}
+	const eps = 1e-9
+
+	// side by side, either way round
+	east := Rect{{10, 22}, {11, 23}}
+	merged, ok := box.MergeIfAligned(east, eps)
+	assert.True(t, ok)
+	assert.Equal(t, Rect{{10, 20}, {11, 23}}, merged)
+	merged, ok = east.MergeIfAligned(box, eps)
+	assert.True(t, ok)
+	assert.Equal(t, Rect{{10, 20}, {11, 23}}, merged)
+
+	// one above the other
+	north := Rect{{11, 20}, {12.5, 22}}
+	merged, ok = box.MergeIfAligned(north, eps)
+	assert.True(t, ok)
+	assert.Equal(t, Rect{{10, 20}, {12.5, 22}}, merged)
+	_, ok = north.MergeIfAligned(box, eps)
+	assert.True(t, ok)
+
+	// the tiles of a rect merge back into it
+	tiles := box.Tile(1, 2)
+	merged, ok = tiles[0].MergeIfAligned(tiles[1], eps)
+	assert.True(t, ok)
+	assert.Equal(t, box, merged)
+
+	// within the tolerance
+	_, ok = box.MergeIfAligned(Rect{{10.0001, 22.0001}, {11, 23}}, 0.001)
+	assert.True(t, ok)
+	_, ok = box.MergeIfAligned(Rect{{10.0001, 22.0001}, {11, 23}}, eps)
+	assert.False(t, ok)
+
+	for _, other := range []Rect{
+		{{10, 21}, {11, 23}},   // overlapping
+		{{10, 20}, {11, 22}},   // the same
+		{{10, 23}, {11, 24}},   // a gap between them
+		{{10.5, 22}, {11, 23}}, // adjacent, but only part of the edge
+		{{11, 22}, {12, 24}},   // touching at the corner
+		{{40, 50}, {41, 52}},   // nowhere near
+	} {
+		_, ok = box.MergeIfAligned(other, eps)
+		assert.False(t, ok, "%v", other)
+	}
+}
+
+func TestPan(t *testing.T) {
+	box := Rect{{10, 20}, {12, 24}}
+	assert.Equal(t, Rect{{11, 20}, {13, 24}}, box.Pan(0.5, 0))
+	assert.Equal(t, Rect{{10, 18}, {12, 22}}, box.Pan(0, -0.5))
+	assert.Equal(t, Rect{{6, 24}, {8, 28}}, box.Pan(-2, 1))
+	assert.Equal(t, box, box.Pan(0, 0))
+
+	// the size stays the same up against the edges
+	top := Rect{{87, 170}, {89, 174}}
+	assert.Equal(t, Rect{{88, 176}, {90, 180}}, top.Pan(1, 2))
+	bottom := Rect{{-89, -178}, {-87, -174}}
+	assert.Equal(t, Rect{{-90, -180}, {-88, -176}}, bottom.Pan(-1, -1))
+}
+
+func TestRectFromCenter(t *testing.T) {
+	center := GeoPoint(AlaLat, AlaLon)
+	box := RectFromCenter(center, 3, 2)
+	assert.InDelta(t, float64(center.Lat), float64(box.Center().Lat), 0.00001)
+	assert.InDelta(t, float64(center.Lon), float64(box.Center().Lon), 0.00001)
+	assert.InDelta(t, 4, (box[1][0]-box[0][0])*DegreeToKilometer, 1e-9)
+	assert.InDelta(t, 6, LongitudeKilometers(float64(center.Lat), box[1][1]-box[0][1]), 1e-9)
+	assert.True(t, box.ContainsPoint(center))
+}
+
+func TestClampToRect(t *testing.T) {
+	box := Rect{{10, 20}, {12, 24}}
+	assert.Equal(t, GeoPoint(11, 21), GeoPoint(11, 21).ClampToRect(box))
+	assert.Equal(t, GeoPoint(12, 21), GeoPoint(15, 21).ClampToRect(box))
+	assert.Equal(t, GeoPoint(10, 24), GeoPoint(5, 30).ClampToRect(box))
+	assert.Equal(t, GeoPoint(11, 20), GeoPoint(11, -5).ClampToRect(box))
+}
+
+func TestExpandToInclude(t *testing.T) {
+	box := Rect{{10, 20}, {12, 24}}
+	assert.Equal(t, box, box.ExpandToInclude(GeoPoint(11, 22)))
+	assert.Equal(t, Rect{{10, 20}, {15, 24}}, box.ExpandToInclude(GeoPoint(15, 22)))
+	assert.Equal(t, Rect{{5, 20}, {12, 24}}, box.ExpandToInclude(GeoPoint(5, 22)))
+	assert.Equal(t, Rect{{10, 20}, {12, 30}}, box.ExpandToInclude(GeoPoint(11, 30)))
+	assert.Equal(t, Rect{{10, -5}, {12, 24}}, box.ExpandToInclude(GeoPoint(11, -5)))
+	assert.Equal(t, Rect{{-1, 20}, {12, 26}}, box.ExpandToInclude(GeoPoint(-1, 26)))
+
+	var grown Rect
+	grown = grown.ExpandToInclude(GeoPoint(11, 22))
+	assert.Equal(t, Rect{{11, 22}, {11, 22}}, grown)
+	grown = grown.ExpandToInclude(GeoPoint(10, 24))
+	assert.Equal(t, Rect{{10, 22}, {11, 24}}, grown)
+}
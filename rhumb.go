@@ -0,0 +1,108 @@
+package geo
+
+import (
+	"math"
+)
+
+// stretched returns the "stretched" (mercator) latitude,
+// in which rhumb lines are straight
+func stretched(latRad float64) float64 {
+	return math.Log(math.Tan(math.Pi/4 + latRad/2))
+}
+
+// wrapRad returns the shortest way round for the longitude difference
+func wrapRad(dlon float64) float64 {
+	switch {
+	case dlon > math.Pi:
+		return dlon - 2*math.Pi
+	case dlon < -math.Pi:
+		return dlon + 2*math.Pi
+	}
+	return dlon
+}
+
+// normalizeLon returns the longitude (in degrees) within -180..180
+func normalizeLon(lon float64) float64 {
+	return math.Mod(math.Mod(lon+180, 360)+360, 360) - 180
+}
+
+// RhumbDistance returns the distance (in Km) along the rhumb line
+// (loxodrome, i.e., constant bearing) between the two points
+func RhumbDistance(a, b Point) float64 {
+	lat1, lat2 := deg2rad(float64(a.Lat)), deg2rad(float64(b.Lat))
+	dlat := lat2 - lat1
+	dlon := wrapRad(deg2rad(float64(b.Lon - a.Lon)))
+	dpsi := stretched(lat2) - stretched(lat1)
+
+	// east-west lines have no change in stretched latitude
+	q := math.Cos(lat1)
+	if math.Abs(dpsi) > 1e-12 {
+		q = dlat / dpsi
+	}
+	return math.Sqrt(dlat*dlat+q*q*dlon*dlon) * EarthRadiusInKM
+}
+
+// RhumbBearing returns the constant bearing (in degrees clockwise from north)
+// of the rhumb line from a to b
+func RhumbBearing(a, b Point) float64 {
+	lat1, lat2 := deg2rad(float64(a.Lat)), deg2rad(float64(b.Lat))
+	dlon := wrapRad(deg2rad(float64(b.Lon - a.Lon)))
+	dpsi := stretched(lat2) - stretched(lat1)
+	return math.Mod(math.Atan2(dlon, dpsi)/Radian+360, 360)
+}
+
+// BearingDelta returns how far (in degrees, -180..180) the initial great circle
+// bearing from a to b is clockwise of the rhumb line bearing.
+// It is near zero for short or north-south legs, but grows large for long
+// east-west legs at high latitudes, where the two paths diverge
+func BearingDelta(a, b Point) float64 {
+	// normalizeLon works just as well for any angle
+	return normalizeLon(a.Bearing(b) - RhumbBearing(a, b))
+}
+
+// RhumbMidpoint returns the point halfway along the rhumb line from a to b,
+// which is not the same as the great circle midpoint
+func RhumbMidpoint(a, b Point) Point {
+	lat1, lat2 := deg2rad(float64(a.Lat)), deg2rad(float64(b.Lat))
+	lon1, lon2 := deg2rad(float64(a.Lon)), deg2rad(float64(b.Lon))
+	// go the short way across the antimeridian
+	switch {
+	case lon2-lon1 > math.Pi:
+		lon1 += 2 * math.Pi
+	case lon2-lon1 < -math.Pi:
+		lon2 += 2 * math.Pi
+	}
+
+	lat3 := (lat1 + lat2) / 2
+	f1, f2, f3 := stretched(lat1), stretched(lat2), stretched(lat3)
+	lon3 := ((lon2-lon1)*f3 + lon1*f2 - lon2*f1) / (f2 - f1)
+	if math.IsNaN(lon3) || math.IsInf(lon3, 0) {
+		// due east or west
+		lon3 = (lon1 + lon2) / 2
+	}
+	return GeoPoint(lat3/Radian, normalizeLon(lon3/Radian))
+}
+
+// RhumbDestination returns the point reached by travelling distanceKm
+// along the rhumb line starting at lat,lon with the constant bearing
+// (in degrees clockwise from north)
+func RhumbDestination(lat, lon, distanceKm, bearingDeg float64) Point {
+	lat1, lon1 := deg2rad(lat), deg2rad(lon)
+	brng := deg2rad(bearingDeg)
+	delta := distanceKm / EarthRadiusInKM
+
+	dlat := delta * math.Cos(brng)
+	lat2 := lat1 + dlat
+	// going past a pole comes back down the other side
+	if math.Abs(lat2) > math.Pi/2 {
+		lat2 = math.Copysign(math.Pi, lat2) - lat2
+	}
+
+	// east-west lines have no change in stretched latitude
+	q := math.Cos(lat1)
+	if dpsi := stretched(lat2) - stretched(lat1); math.Abs(dpsi) > 1e-12 {
+		q = dlat / dpsi
+	}
+	lon2 := lon1 + delta*math.Sin(brng)/q
+	return GeoPoint(lat2/Radian, normalizeLon(lon2/Radian))
+}
@@ -0,0 +1,99 @@
+package geo
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRhumbDistance(t *testing.T) {
+	// due north, it's the same as the great circle
+	a, b := GeoPoint(10, 20), GeoPoint(30, 20)
+	assert.InDelta(t, a.Distance(b), RhumbDistance(a, b), 0.01)
+	assert.InDelta(t, 0.0, RhumbBearing(a, b), 1e-9)
+
+	// otherwise it's longer
+	sf, hou := GeoPoint(SFLat, SFLon), GeoPoint(HouLat, HouLon)
+	assert.Greater(t, RhumbDistance(sf, hou), sf.Distance(hou))
+
+	// along a parallel
+	a, b = GeoPoint(60, 0), GeoPoint(60, 10)
+	assert.InDelta(t, LongitudeKilometers(60, 10)*EarthRadiusInKM*Radian/DegreeToKilometer, RhumbDistance(a, b), 0.01)
+	assert.InDelta(t, 90.0, RhumbBearing(a, b), 1e-9)
+}
+
+func TestBearingDelta(t *testing.T) {
+	// due north or south, both follow the meridian
+	assert.InDelta(t, 0, BearingDelta(GeoPoint(SFLat, SFLon), GeoPoint(SFLat+1, SFLon)), 1e-6)
+	assert.InDelta(t, 0, BearingDelta(GeoPoint(10, 20), GeoPoint(-30, 20)), 1e-6)
+	// along the equator, both go due east
+	assert.InDelta(t, 0, BearingDelta(GeoPoint(0, 0), GeoPoint(0, 40)), 1e-6)
+	// short legs barely differ
+	assert.InDelta(t, 0, BearingDelta(GeoPoint(SFLat, SFLon), GeoPoint(ZepLat, ZepLon)), 1)
+
+	// a long leg east at high latitude heads well north of east to start with
+	delta := BearingDelta(GeoPoint(60, -150), GeoPoint(60, -60))
+	assert.Less(t, delta, -30.0)
+	// and heading west, the great circle starts off north of west instead
+	assert.Greater(t, BearingDelta(GeoPoint(60, -60), GeoPoint(60, -150)), 30.0)
+}
+
+func TestRhumbMidpoint(t *testing.T) {
+	tests := []struct {
+		a, b Point
+	}{
+		{GeoPoint(SFLat, SFLon), GeoPoint(HouLat, HouLon)},
+		{GeoPoint(51.1, -1.3), GeoPoint(49.5, 2.3)},
+		{GeoPoint(10, 170), GeoPoint(20, -170)}, // across the antimeridian
+		{GeoPoint(-20, -170), GeoPoint(-10, 170)},
+	}
+	for _, tt := range tests {
+		mid := RhumbMidpoint(tt.a, tt.b)
+		brng := RhumbBearing(tt.a, tt.b)
+		// it's on the same line
+		assert.InDelta(t, brng, RhumbBearing(tt.a, mid), 0.01, "%v -> %v", tt.a, mid)
+		assert.InDelta(t, brng, RhumbBearing(mid, tt.b), 0.01, "%v -> %v", mid, tt.b)
+		// and it's halfway
+		dist := RhumbDistance(tt.a, tt.b)
+		assert.InDelta(t, dist/2, RhumbDistance(tt.a, mid), 0.01)
+		assert.InDelta(t, dist/2, RhumbDistance(mid, tt.b), 0.01)
+	}
+	mid := RhumbMidpoint(GeoPoint(10, 170), GeoPoint(20, -170))
+	// near the antimeridian, not the prime meridian
+	assert.InDelta(t, 180.0, math.Abs(float64(mid.Lon)), 0.5)
+
+	// due east
+	mid = RhumbMidpoint(GeoPoint(45, 10), GeoPoint(45, 20))
+	assert.Equal(t, GeoPoint(45, 15), mid)
+}
+
+func TestRhumbDestination(t *testing.T) {
+	start := GeoPoint(SFLat, SFLon)
+	for _, brng := range []float64{0, 30, 90, 135, 200, 270, 315} {
+		for _, km := range []float64{1, 100, 2500} {
+			end := RhumbDestination(SFLat, SFLon, km, brng)
+			assert.InDelta(t, km, RhumbDistance(start, end), 0.005, "%.0fkm at %.0f°", km, brng)
+			if km < 100 {
+				// too short to get a precise bearing from float32 coordinates
+				continue
+			}
+			// each step along the way stays on the same heading
+			for _, part := range []float64{0.25, 0.5, 0.75} {
+				mid := RhumbDestination(SFLat, SFLon, km*part, brng)
+				assert.InDelta(t, brng, RhumbBearing(start, mid), 0.05, "%.0fkm at %.0f°", km, brng)
+			}
+		}
+	}
+
+	// due east stays on the parallel
+	end := RhumbDestination(60, 10, 500, 90)
+	assert.InDelta(t, 60.0, float64(end.Lat), 1e-9)
+	assert.InDelta(t, 500.0, RhumbDistance(GeoPoint(60, 10), end), 0.005)
+
+	// across the antimeridian
+	end = RhumbDestination(10, 179.5, 111.2, 90)
+	assert.Less(t, float64(end.Lon), -179.0)
+	end = RhumbDestination(-10, -179.5, 111.2, 270)
+	assert.Greater(t, float64(end.Lon), 179.0)
+}
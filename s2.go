@@ -0,0 +1,186 @@
+package geo
+
+import (
+	"math"
+	"math/bits"
+)
+
+// S2 cell IDs, as used by the Google S2 geometry library, number the cells
+// of a cube projected onto the sphere. Each of the 6 faces is divided
+// into quarters, recursively, down to level 30 (about 1cm across),
+// and the cells of each level are numbered along a Hilbert curve.
+//
+// An ID is 3 bits for the face, 2 bits for each level of the curve,
+// then a 1 bit marking where the position ends (the rest are zero)
+const (
+	s2MaxLevel = 30
+	s2PosBits  = 2*s2MaxLevel + 1
+	s2MaxSize  = 1 << s2MaxLevel
+
+	// the curve is walked 4 levels (8 bits) at a time using lookup tables
+	s2LookupBits = 4
+	s2SwapMask   = 0x01
+	s2InvertMask = 0x02
+)
+
+var (
+	// the order the quadrants (i<<1 | j) are visited in for each orientation
+	s2PosToIJ = [4][4]int{
+		{0, 1, 3, 2}, // canonical order
+		{0, 2, 3, 1}, // axes swapped
+		{3, 2, 0, 1}, // bits inverted
+		{3, 1, 0, 2}, // swapped and inverted
+	}
+	// the change in orientation entering each of the quadrants
+	s2PosToOrientation = [4]int{s2SwapMask, 0, 0, s2InvertMask | s2SwapMask}
+
+	s2LookupPos [1 << (2*s2LookupBits + 2)]int
+	s2LookupIJ  [1 << (2*s2LookupBits + 2)]int
+)
+
+func init() {
+	for _, orientation := range []int{0, s2SwapMask, s2InvertMask, s2SwapMask | s2InvertMask} {
+		s2InitLookup(0, 0, 0, orientation, 0, orientation)
+	}
+}
+
+// s2InitLookup fills in the lookup tables between i,j and the
+// position on the curve, for all the cells s2LookupBits levels down
+func s2InitLookup(level, i, j, origOrientation, pos, orientation int) {
+	if level == s2LookupBits {
+		ij := i<<s2LookupBits + j
+		s2LookupPos[ij<<2+origOrientation] = pos<<2 + orientation
+		s2LookupIJ[pos<<2+origOrientation] = ij<<2 + orientation
+		return
+	}
+	for p, quad := range s2PosToIJ[orientation] {
+		s2InitLookup(level+1, i<<1+quad>>1, j<<1+quad&1, origOrientation,
+			pos<<2+p, orientation^s2PosToOrientation[p])
+	}
+}
+
+// s2STToUV converts from cell space (0..1) to the cube face (-1..1),
+// using the quadratic transform that makes the cells closer to equal in area
+func s2STToUV(s float64) float64 {
+	if s >= 0.5 {
+		return (4*s*s - 1) / 3
+	}
+	return (1 - 4*(1-s)*(1-s)) / 3
+}
+
+// s2UVToST is the inverse of s2STToUV
+func s2UVToST(u float64) float64 {
+	if u >= 0 {
+		return 0.5 * math.Sqrt(1+3*u)
+	}
+	return 1 - 0.5*math.Sqrt(1-3*u)
+}
+
+// s2FaceUV returns the cube face the vector points at, and where on it
+func s2FaceUV(v vector) (face int, u, w float64) {
+	face = 0
+	if math.Abs(v[1]) > math.Abs(v[face]) {
+		face = 1
+	}
+	if math.Abs(v[2]) > math.Abs(v[face]) {
+		face = 2
+	}
+	if v[face] < 0 {
+		face += 3
+	}
+	switch face {
+	case 0:
+		return face, v[1] / v[0], v[2] / v[0]
+	case 1:
+		return face, -v[0] / v[1], v[2] / v[1]
+	case 2:
+		return face, -v[0] / v[2], -v[1] / v[2]
+	case 3:
+		return face, v[2] / v[0], v[1] / v[0]
+	case 4:
+		return face, v[2] / v[1], -v[0] / v[1]
+	default:
+		return face, -v[1] / v[2], -v[0] / v[2]
+	}
+}
+
+// s2FaceVector is the inverse of s2FaceUV (though the vector is not unit length)
+func s2FaceVector(face int, u, w float64) vector {
+	switch face {
+	case 0:
+		return vector{1, u, w}
+	case 1:
+		return vector{-u, 1, w}
+	case 2:
+		return vector{-u, -w, 1}
+	case 3:
+		return vector{-1, -w, -u}
+	case 4:
+		return vector{w, -1, -u}
+	default:
+		return vector{w, u, -1}
+	}
+}
+
+// s2STToIJ returns the leaf cell coordinate (0..s2MaxSize-1) for s
+func s2STToIJ(s float64) int {
+	return int(math.Max(0, math.Min(s2MaxSize-1, math.Floor(s2MaxSize*s))))
+}
+
+// S2CellID returns the ID of the S2 cell at the given level (0..30)
+// that contains the point, with the level clamped to that range
+func (p Point) S2CellID(level int) uint64 {
+	switch {
+	case level < 0:
+		level = 0
+	case level > s2MaxLevel:
+		level = s2MaxLevel
+	}
+	face, u, w := s2FaceUV(toVector(p))
+	i, j := s2STToIJ(s2UVToST(u)), s2STToIJ(s2UVToST(w))
+
+	id := uint64(face) << (s2PosBits - 1)
+	bits := face & s2SwapMask
+	const mask = 1<<s2LookupBits - 1
+	for k := 7; k >= 0; k-- {
+		bits += (i >> (k * s2LookupBits) & mask) << (s2LookupBits + 2)
+		bits += (j >> (k * s2LookupBits) & mask) << 2
+		bits = s2LookupPos[bits]
+		id |= uint64(bits>>2) << (k * 2 * s2LookupBits)
+		bits &= s2SwapMask | s2InvertMask
+	}
+	// the leaf cell, then cut down to the level requested
+	id = id*2 + 1
+	lsb := uint64(1) << (2 * (s2MaxLevel - level))
+	return id&-lsb | lsb
+}
+
+// S2CellToPoint returns the center of the S2 cell and its level,
+// or a level of -1 if the ID is not a valid cell
+func S2CellToPoint(id uint64) (Point, int) {
+	face := int(id >> s2PosBits)
+	// the lowest set bit marks the level, so must be at an even position
+	if lsb := id & -id; face > 5 || lsb&0x1555555555555555 == 0 {
+		return Point{}, -1
+	}
+	level := s2MaxLevel - bits.TrailingZeros64(id)/2
+
+	var i, j int
+	orientation := face & s2SwapMask
+	// the first step has only the 2 bits left over from the 28 of the others
+	nbits := s2MaxLevel - 7*s2LookupBits
+	for k := 7; k >= 0; k-- {
+		orientation += int(id>>(k*2*s2LookupBits+1)) & (1<<(2*nbits) - 1) << 2
+		orientation = s2LookupIJ[orientation]
+		i += orientation >> (s2LookupBits + 2) << (k * s2LookupBits)
+		j += (orientation >> 2 & (1<<s2LookupBits - 1)) << (k * s2LookupBits)
+		orientation &= s2SwapMask | s2InvertMask
+		nbits = s2LookupBits
+	}
+
+	// i,j is the lower left leaf of the cell, so step to its middle
+	size := 1 << (s2MaxLevel - level)
+	s := (float64(i&^(size-1)) + float64(size)/2) / s2MaxSize
+	t := (float64(j&^(size-1)) + float64(size)/2) / s2MaxSize
+	return s2FaceVector(face, s2STToUV(s), s2STToUV(t)).point(), level
+}
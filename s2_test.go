@@ -0,0 +1,78 @@
+package geo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// leaf cells and their centers, per the S2 reference implementation
+var s2Samples = []struct {
+	id       uint64
+	lat, lon float64
+}{
+	{0x47a1cbd595522b39, 49.703498679, 11.770681595},
+	{0x46525318b63be0f9, 55.685376759, 12.588490937},
+	{0x52b30b71698e729d, 45.486546517, -93.449700022},
+	{0x46ed8886cfadda85, 58.299984854, 23.049300056},
+	{0x3663f18a24cbe857, 34.364439040, 108.330699969},
+	{0x10a06c0a948cf5d, -30.694551352, -30.048758753},
+	{0x2b2bfd076787c5df, -25.285264027, 133.823116966},
+	{0xb09dff882a7809e1, -75.000000031, 0.000000133},
+	{0x94daa3d000000001, -24.694439215, -47.537363213},
+	{0x87a1000000000001, 38.899730392, -99.901813021},
+	{0x4fc76d5000000001, 81.647200334, -55.631712940},
+	{0x3b00955555555555, 10.050986518, 78.293170610},
+	{0x1dcc469991555555, -34.055420593, 18.551140038},
+	{0xb112966aaaaaaaab, -69.219262171, 49.670072392},
+}
+
+// s2Parent returns the ID of the cell at the level containing the cell
+func s2Parent(id uint64, level int) uint64 {
+	lsb := uint64(1) << (2 * (s2MaxLevel - level))
+	return id&-lsb | lsb
+}
+
+func TestS2CellID(t *testing.T) {
+	// the points are only float32, so compare well above leaf level
+	// (the later samples sit right on the edges of larger cells)
+	for _, tt := range s2Samples[:8] {
+		pt := GeoPoint(tt.lat, tt.lon)
+		assert.Equal(t, s2Parent(tt.id, 20), pt.S2CellID(20), "%x", tt.id)
+	}
+
+	// the faces
+	assert.Equal(t, uint64(0x1000000000000000), GeoPoint(0, 0).S2CellID(0))
+	assert.Equal(t, uint64(0x3000000000000000), GeoPoint(0, 90).S2CellID(0))
+	assert.Equal(t, uint64(0x5000000000000000), GeoPoint(90, 0).S2CellID(0))
+	assert.Equal(t, uint64(0x7000000000000000), GeoPoint(0, 180).S2CellID(0))
+	assert.Equal(t, uint64(0xb000000000000000), GeoPoint(-90, 0).S2CellID(0))
+
+	// out of range levels are clamped
+	pt := GeoPoint(AlaLat, AlaLon)
+	assert.Equal(t, pt.S2CellID(0), pt.S2CellID(-1))
+	assert.Equal(t, pt.S2CellID(30), pt.S2CellID(31))
+}
+
+func TestS2CellToPoint(t *testing.T) {
+	for _, tt := range s2Samples {
+		pt, level := S2CellToPoint(tt.id)
+		assert.Equal(t, 30, level)
+		assert.InDelta(t, tt.lat, float64(pt.Lat), 0.00001, "%x", tt.id)
+		assert.InDelta(t, tt.lon, float64(pt.Lon), 0.00001, "%x", tt.id)
+	}
+
+	// the center of a cell is in that cell
+	for _, level := range []int{0, 1, 5, 12, 20} {
+		id := GeoPoint(AlaLat, AlaLon).S2CellID(level)
+		center, got := S2CellToPoint(id)
+		assert.Equal(t, level, got)
+		assert.Equal(t, id, center.S2CellID(level), "level %d", level)
+	}
+
+	// no face, no marker bit, or the marker bit at an odd position
+	for _, id := range []uint64{0, 0xd000000000000000, 0x1000000000000002, 0x1000000000000008, 0x1000000000000000 | 1<<59} {
+		_, level := S2CellToPoint(id)
+		assert.Equal(t, -1, level, "%x", id)
+	}
+}
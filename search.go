@@ -0,0 +1,373 @@
+package geo
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+var unsortedWarning sync.Once
+
+// IsSorted returns true if the points are in the (lat, lon) order
+// that Bestest and Closest depend upon
+func IsSorted(g GeoPoints) bool {
+	return FirstUnsorted(g) < 0
+}
+
+// FirstUnsorted returns the index of the first point that sorts before
+// the point preceding it, or -1 if the points are sorted
+func FirstUnsorted(g GeoPoints) int {
+	for i := 1; i < g.Len(); i++ {
+		if g.IndexPoint(i).Less(g.IndexPoint(i - 1)) {
+			return i
+		}
+	}
+	return -1
+}
+
+// NearestAuto is like Bestest, but verifies the points are sorted first.
+// If they are not, it falls back to a (much slower) scan of every point.
+//
+// NOTE: the sort check itself visits every point, so callers that know
+// their data is sorted should use Bestest directly
+func NearestAuto(g GeoPoints, pt Point, deltaKm float64) (int, float64) {
+	if IsSorted(g) {
+		return Bestest(g, pt, deltaKm)
+	}
+	// only warn once, but not until there is somewhere to warn to
+	if DebugLogger != nil {
+		unsortedWarning.Do(func() {
+			debugf("points are not sorted, falling back to a full scan")
+		})
+	}
+	return bruteForce(g, pt, deltaKm)
+}
+
+// bruteForce checks every point for the closest within deltaKm.
+// Like Bestest, it returns the Len() of the points and -1 if nothing is found
+func bruteForce(g GeoPoints, pt Point, deltaKm float64) (int, float64) {
+	best, closest := g.Len(), -1.0
+	for i := 0; i < g.Len(); i++ {
+		dist := pt.Distance(g.IndexPoint(i))
+		if dist > deltaKm {
+			continue
+		}
+		if closest < 0 || dist < closest {
+			best, closest = i, dist
+		}
+	}
+	return best, closest
+}
+
+type candidate struct {
+	idx  int
+	dist float64
+}
+
+// candidates is a min-heap of points by distance
+type candidates []candidate
+
+func (c candidates) Len() int            { return len(c) }
+func (c candidates) Less(i, j int) bool  { return c[i].dist < c[j].dist }
+func (c candidates) Swap(i, j int)       { c[i], c[j] = c[j], c[i] }
+func (c *candidates) Push(x interface{}) { *c = append(*c, x.(candidate)) }
+func (c *candidates) Pop() interface{} {
+	old := *c
+	last := old[len(old)-1]
+	*c = old[:len(old)-1]
+	return last
+}
+
+// farthest is a max-heap of distances
+type farthest []float64
+
+func (f farthest) Len() int            { return len(f) }
+func (f farthest) Less(i, j int) bool  { return f[i] > f[j] }
+func (f farthest) Swap(i, j int)       { f[i], f[j] = f[j], f[i] }
+func (f *farthest) Push(x interface{}) { *f = append(*f, x.(float64)) }
+func (f *farthest) Pop() interface{} {
+	old := *f
+	last := old[len(old)-1]
+	*f = old[:len(old)-1]
+	return last
+}
+
+// sweep yields the points of a sorted GeoPoints in order of distance
+// from pt, expanding outward from where pt would sort in the list.
+//
+// A point can't be any closer than its difference in latitude,
+// so a candidate is only returned once every point left unseen
+// is further away in latitude alone, and the sweep ends once
+// that is beyond maxKm.
+type sweep struct {
+	g      GeoPoints
+	pt     Point
+	maxKm  float64
+	lo, hi int // the next points to examine below and above
+	found  candidates
+}
+
+// newSweep returns a sweep of the points within maxKm of pt
+// (which can be math.Inf(1) to sweep them all)
+func newSweep(g GeoPoints, pt Point, maxKm float64) *sweep {
+	x := sort.Search(g.Len(), func(i int) bool {
+		return pt.Less(g.IndexPoint(i))
+	})
+	return &sweep{g: g, pt: pt, maxKm: maxKm, lo: x - 1, hi: x}
+}
+
+// latKm is the least distance possible to a point at index i
+func (s *sweep) latKm(i int) float64 {
+	if i < 0 || i >= s.g.Len() {
+		return math.Inf(1)
+	}
+	return math.Abs(float64(s.g.IndexPoint(i).Lat-s.pt.Lat)) * DegreeToKilometer
+}
+
+// next returns the index and distance of the next closest point,
+// and false once every point within maxKm has been returned
+func (s *sweep) next() (int, float64, bool) {
+	for {
+		below, above := s.latKm(s.lo), s.latKm(s.hi)
+		// a point without a latitude (from bad data) can't be placed
+		if math.IsNaN(below) {
+			s.lo--
+			continue
+		}
+		if math.IsNaN(above) {
+			s.hi++
+			continue
+		}
+		bound := math.Min(below, above)
+		if len(s.found) > 0 && s.found[0].dist <= bound {
+			c := heap.Pop(&s.found).(candidate)
+			return c.idx, c.dist, true
+		}
+		if math.IsInf(bound, 1) || bound > s.maxKm {
+			// nothing left, or nothing left close enough
+			return s.g.Len(), -1, false
+		}
+		i := s.hi
+		if below < above {
+			i = s.lo
+			s.lo--
+		} else {
+			s.hi++
+		}
+		dist := s.pt.Distance(s.g.IndexPoint(i))
+		if !(dist <= s.maxKm) {
+			// too far, or NaN
+			continue
+		}
+		heap.Push(&s.found, candidate{i, dist})
+	}
+}
+
+// KNearest returns the indexes of (up to) the k closest points
+// within deltaKm of pt, and their distances, closest first
+func KNearest(g GeoPoints, pt Point, k int, deltaKm float64) ([]int, []float64) {
+	var idx []int
+	var dists []float64
+	s := newSweep(g, pt, deltaKm)
+	for len(idx) < k {
+		i, dist, ok := s.next()
+		if !ok {
+			break
+		}
+		idx = append(idx, i)
+		dists = append(dists, dist)
+	}
+	return idx, dists
+}
+
+// NearestExcluding is Bestest, but skips the points whose index is in excluded,
+// e.g., to find the nearest point that has not been matched already.
+// It returns the Len() of the points and -1 if there is none
+func NearestExcluding(g GeoPoints, pt Point, deltaKm float64, excluded map[int]bool) (int, float64) {
+	s := newSweep(g, pt, deltaKm)
+	for {
+		i, dist, ok := s.next()
+		if !ok {
+			return g.Len(), -1
+		}
+		if !excluded[i] {
+			return i, dist
+		}
+	}
+}
+
+// KNearestAdaptive is KNearest without having to guess at the range:
+// it keeps sweeping outwards from pt until it finds k points
+// (or there are no more points to find), so sparse data costs more
+func KNearestAdaptive(g GeoPoints, pt Point, k int) ([]int, []float64) {
+	if k < 1 {
+		return nil, nil
+	}
+	var idx []int
+	var dists []float64
+	s := newSweep(g, pt, math.Inf(1))
+	for len(idx) < k {
+		i, dist, ok := s.next()
+		if !ok {
+			break
+		}
+		idx = append(idx, i)
+		dists = append(dists, dist)
+	}
+	return idx, dists
+}
+
+// scanBox calls fn with the index of each point within the box
+// (and the point itself), until fn returns false.
+// Like ranger for an Iter, it only examines the points in the box's
+// latitude range, so the points must be sorted
+func scanBox(g GeoPoints, box Rect, fn func(i int, this Point) bool) {
+	minLat, maxLat := GeoType(box[0][0]), GeoType(box[1][0])
+	minLon, maxLon := GeoType(box[0][1]), GeoType(box[1][1])
+	from := sort.Search(g.Len(), func(i int) bool {
+		return g.IndexPoint(i).Lat >= minLat
+	})
+	for i := from; i < g.Len(); i++ {
+		this := g.IndexPoint(i)
+		if this.Lat > maxLat {
+			break
+		}
+		if this.Lon < minLon || this.Lon > maxLon {
+			continue
+		}
+		if !fn(i, this) {
+			return
+		}
+	}
+}
+
+// scanWithin calls fn with the index and distance of each point
+// within radiusKm of pt (in the order they are stored, not by distance),
+// until fn returns false
+func scanWithin(g GeoPoints, pt Point, radiusKm float64, fn func(i int, dist float64) bool) {
+	scanBox(g, pt.BoundingBox(radiusKm), func(i int, this Point) bool {
+		if dist := pt.Distance(this); dist <= radiusKm {
+			return fn(i, dist)
+		}
+		return true
+	})
+}
+
+// NearestWeighted returns the index of the point within deltaKm of pt
+// with the least distance plus weight(i), and that weighted distance.
+// Like Bestest, it returns the Len() of the points and -1 if nothing is found.
+//
+// Only points within deltaKm are considered, regardless of their weight,
+// so a wide range of weights may require a wider deltaKm for
+// a lightly weighted but further point to be found
+func NearestWeighted(g GeoPoints, pt Point, deltaKm float64, weight func(i int) float64) (int, float64) {
+	best, closest := g.Len(), -1.0
+	scanWithin(g, pt, deltaKm, func(i int, dist float64) bool {
+		if cost := dist + weight(i); closest < 0 || cost < closest {
+			best, closest = i, cost
+		}
+		return true
+	})
+	return best, closest
+}
+
+// KthDistance returns the distance to the k-th nearest point within maxKm
+// of pt (k=1 being the nearest), and false if there are fewer than k.
+// Only the k nearest distances are kept along the way,
+// so it's cheaper than KNearest when just the distance is wanted
+func KthDistance(g GeoPoints, pt Point, k int, maxKm float64) (float64, bool) {
+	if k < 1 {
+		return -1, false
+	}
+	nearest := make(farthest, 0, k)
+	scanWithin(g, pt, maxKm, func(_ int, dist float64) bool {
+		switch {
+		case len(nearest) < k:
+			heap.Push(&nearest, dist)
+		case dist < nearest[0]:
+			nearest[0] = dist
+			heap.Fix(&nearest, 0)
+		}
+		return true
+	})
+	if len(nearest) < k {
+		return -1, false
+	}
+	return nearest[0], true
+}
+
+// NearestAhead is Bestest for a moving point, returning the nearest point
+// within deltaKm that is ahead of it, i.e., whose bearing from pt is within
+// 90 degrees of the heading. Points behind are treated as out of range.
+func NearestAhead(g GeoPoints, pt Point, headingDeg, deltaKm float64) (int, float64) {
+	ahead := func(a, b Point) float64 {
+		if math.Abs(TurnAngle(headingDeg, a.Bearing(b))) > 90 {
+			return math.Inf(1)
+		}
+		return a.Distance(b)
+	}
+	idx, _, dist, _ := search(g, pt, deltaKm, ahead, true)
+	return idx, dist
+}
+
+// AnyWithin returns the index of a point within thresholdKm of pt,
+// and false if there are none. It stops at the first point found,
+// which is not necessarily the nearest, making it cheaper than Bestest
+// for checking if there is anything nearby at all
+func AnyWithin(g GeoPoints, pt Point, thresholdKm float64) (int, bool) {
+	found := g.Len()
+	scanWithin(g, pt, thresholdKm, func(i int, _ float64) bool {
+		found = i
+		return false
+	})
+	return found, found < g.Len()
+}
+
+// BatchNearestDistances returns the distance from each of the queries
+// to its nearest point (using Bestest), or -1 if there is none
+// within deltaKm, e.g., for the distribution of match distances
+func BatchNearestDistances(g GeoPoints, queries []Point, deltaKm float64) []float64 {
+	dists := make([]float64, len(queries))
+	for i, pt := range queries {
+		_, dists[i] = Bestest(g, pt, deltaKm)
+	}
+	return dists
+}
+
+// Farthest returns the index of the point within the box that is
+// farthest from pt, and its distance, e.g., for the most remote location
+// in a region. Like Ranger, only the points in the box are examined.
+// It returns -1 (for both) if there are no points in the box
+func Farthest(g GeoPoints, pt Point, box Rect) (int, float64) {
+	best, farthest := -1, -1.0
+	scanBox(g, box, func(i int, this Point) bool {
+		if dist := pt.Distance(this); dist > farthest {
+			best, farthest = i, dist
+		}
+		return true
+	})
+	return best, farthest
+}
+
+// RingCounts returns how many points fall within each ring around pt,
+// where the rings are bounded by edgesKm (which must be in increasing order),
+// e.g., edges of 1, 5, and 10 count the points within 1km, from 1 to 5km,
+// and from 5 to 10km. A point right on an edge counts in the inner ring
+func RingCounts(g GeoPoints, pt Point, edgesKm []float64) ([]int, error) {
+	if len(edgesKm) == 0 {
+		return nil, fmt.Errorf("no ring edges given")
+	}
+	for i, edge := range edgesKm {
+		if !(edge >= 0) || (i > 0 && edge <= edgesKm[i-1]) {
+			return nil, fmt.Errorf("ring edges must be increasing and not negative: %v", edgesKm)
+		}
+	}
+	counts := make([]int, len(edgesKm))
+	scanWithin(g, pt, edgesKm[len(edgesKm)-1], func(_ int, dist float64) bool {
+		counts[sort.SearchFloat64s(edgesKm, dist)]++
+		return true
+	})
+	return counts, nil
+}
@@ -0,0 +1,461 @@
+package geo
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func shuffled(pts testPoints) testPoints {
+	mixed := make(testPoints, len(pts))
+	copy(mixed, pts)
+	r := rand.New(rand.NewSource(1))
+	r.Shuffle(len(mixed), mixed.Swap)
+	return mixed
+}
+
+func TestNearestAuto(t *testing.T) {
+	saved := DebugLogger
+	defer func() { DebugLogger = saved }()
+	DebugLogger = t.Logf
+
+	pts := samplePoints()
+	assert.True(t, IsSorted(pts))
+	mixed := shuffled(pts)
+	assert.False(t, IsSorted(mixed))
+
+	for _, pt := range []Point{
+		GeoPoint(AlaLat+0.051, AlaLon+0.049),
+		GeoPoint(AlaLat+0.123, AlaLon+0.177),
+		GeoPoint(AlaLat-0.001, AlaLon-0.001),
+	} {
+		idx, dist := Bestest(pts, pt, 1.0)
+		sidx, sdist := NearestAuto(pts, pt, 1.0)
+		assert.Equal(t, idx, sidx)
+		assert.Equal(t, dist, sdist)
+
+		midx, mdist := NearestAuto(mixed, pt, 1.0)
+		assert.Equal(t, pts[idx], mixed[midx])
+		assert.InDelta(t, dist, mdist, 1e-9)
+	}
+
+	// nothing in range
+	idx, dist := NearestAuto(mixed, GeoPoint(0, 0), 1.0)
+	assert.Equal(t, mixed.Len(), idx)
+	assert.Equal(t, -1.0, dist)
+}
+
+func TestNearestAutoWarning(t *testing.T) {
+	saved := DebugLogger
+	defer func() { DebugLogger = saved }()
+	unsortedWarning = sync.Once{}
+
+	// the warning isn't used up before there's a logger to see it
+	mixed := shuffled(samplePoints())
+	pt := GeoPoint(AlaLat+0.051, AlaLon+0.049)
+	DebugLogger = nil
+	NearestAuto(mixed, pt, 1.0)
+
+	var logged []string
+	DebugLogger = func(format string, args ...interface{}) {
+		logged = append(logged, fmt.Sprintf(format, args...))
+	}
+	NearestAuto(mixed, pt, 1.0)
+	NearestAuto(mixed, pt, 1.0)
+	assert.Equal(t, []string{"points are not sorted, falling back to a full scan"}, logged)
+}
+
+func TestKNearest(t *testing.T) {
+	pts := samplePoints()
+	pt := GeoPoint(AlaLat+0.051, AlaLon+0.049)
+	idx, dists := KNearest(pts, pt, 10, 5.0)
+	assert.Len(t, idx, 10)
+
+	// compare to the hard way
+	all := make(candidates, 0, len(pts))
+	for i := range pts {
+		all = append(all, candidate{i, pt.Distance(pts[i])})
+	}
+	sort.Stable(all)
+	for i := range idx {
+		assert.Equal(t, all[i].dist, dists[i])
+	}
+	best, _ := Bestest(pts, pt, 5.0)
+	assert.Equal(t, best, idx[0])
+
+	// limited by range
+	idx, _ = KNearest(pts, pt, 10, 0.5)
+	assert.Len(t, idx, 1)
+	idx, _ = KNearest(pts, GeoPoint(0, 0), 10, 5.0)
+	assert.Empty(t, idx)
+}
+
+func TestKNearestExactMatch(t *testing.T) {
+	// identical points used to be a NaN distance apart at this latitude
+	pt := GeoPoint(12.75, 21.675)
+	pts := testPoints{pt, {pt.Lat, pt.Lon + 0.001}, {pt.Lat + 0.001, pt.Lon}}
+
+	idx, dists := KNearest(pts, pt, 2, 1)
+	assert.Equal(t, []int{0, 1}, idx)
+	assert.Equal(t, 0.0, dists[0])
+
+	best, dist := NearestExcluding(pts, pt, 1, nil)
+	assert.Equal(t, 0, best)
+	assert.Equal(t, 0.0, dist)
+
+	m := &MFile{B: encodePoints(pts)}
+	next := m.NewIter(&pointRecord{}).NearestIterator(pt)
+	rec, dist, ok := next()
+	assert.True(t, ok)
+	assert.Equal(t, pt, rec.(*pointRecord).Point())
+	assert.Equal(t, 0.0, dist)
+}
+
+func TestKNearestNaN(t *testing.T) {
+	nan := GeoType(math.NaN())
+	pts := testPoints{{1, 1}, {2, 2}, {nan, 3}}
+	pt := GeoPoint(5, 5)
+	assert.NotPanics(t, func() {
+		idx, _ := KNearest(pts, pt, 5, 10000)
+		assert.Equal(t, []int{1, 0}, idx)
+		idx, _ = KNearestAdaptive(pts, pt, 5)
+		assert.Equal(t, []int{1, 0}, idx)
+		best, _ := NearestExcluding(pts, pt, 10000, map[int]bool{1: true})
+		assert.Equal(t, 0, best)
+	})
+
+	// or before them
+	pts = testPoints{{nan, 3}, {1, 1}, {2, 2}}
+	assert.NotPanics(t, func() {
+		idx, _ := KNearest(pts, GeoPoint(-5, -5), 5, 10000)
+		assert.Equal(t, []int{1, 2}, idx)
+	})
+	idx, _ := KNearest(pts, pt, 5, math.NaN())
+	assert.Empty(t, idx)
+}
+
+// countingPoints counts the points examined by a search
+type countingPoints struct {
+	testPoints
+	calls int
+}
+
+func (c *countingPoints) IndexPoint(i int) Point {
+	c.calls++
+	return c.testPoints.IndexPoint(i)
+}
+
+func TestKNearestBounded(t *testing.T) {
+	// a long thin band of points, most of them far off in longitude
+	var pts testPoints
+	for lat := 0; lat < 20; lat++ {
+		for lon := 0; lon < 500; lon++ {
+			pts = append(pts, GeoPoint(AlaLat+float64(lat)*0.001, AlaLon+float64(lon)*0.01))
+		}
+	}
+	sort.Sort(pts)
+	pt := GeoPoint(AlaLat+0.0101, AlaLon+2.5)
+
+	c := &countingPoints{testPoints: pts}
+	idx, dists := KNearest(c, pt, 1, 1)
+	assert.Len(t, idx, 1)
+	want, wantDist := Bestest(pts, pt, 1)
+	assert.Equal(t, want, idx[0])
+	assert.Equal(t, wantDist, dists[0])
+	// only the rows within 1km, not the whole band
+	t.Logf("examined %d of %d points", c.calls, len(pts))
+	assert.Less(t, c.calls, len(pts)/2)
+
+	// nothing within range stops as soon as it's out of range
+	c.calls = 0
+	idx, _ = KNearest(c, GeoPoint(AlaLat+1, AlaLon+2.5), 1, 1)
+	assert.Empty(t, idx)
+	assert.Less(t, c.calls, 50)
+
+	// likewise when skipping the nearest
+	c.calls = 0
+	best, dist := NearestExcluding(c, pt, 1, map[int]bool{want: true})
+	assert.NotEqual(t, want, best)
+	assert.Less(t, best, len(pts))
+	assert.Greater(t, dist, wantDist)
+	assert.Less(t, c.calls, len(pts)/2)
+}
+
+func TestKthDistance(t *testing.T) {
+	pts := samplePoints()
+	pt := GeoPoint(AlaLat+0.051, AlaLon+0.049)
+	const maxKm = 5.0
+
+	// the hard way
+	var dists []float64
+	for i := range pts {
+		if dist := pt.Distance(pts[i]); dist <= maxKm {
+			dists = append(dists, dist)
+		}
+	}
+	sort.Float64s(dists)
+	if len(dists) < 10 {
+		t.Fatalf("only %d points in range", len(dists))
+	}
+
+	for _, k := range []int{1, 2, 10, len(dists)} {
+		dist, ok := KthDistance(pts, pt, k, maxKm)
+		assert.True(t, ok, "k=%d", k)
+		assert.Equal(t, dists[k-1], dist, "k=%d", k)
+	}
+	_, ok := KthDistance(pts, pt, len(dists)+1, maxKm)
+	assert.False(t, ok)
+	_, ok = KthDistance(pts, pt, 0, maxKm)
+	assert.False(t, ok)
+
+	// the nearest to a stored point is itself
+	a := GeoPoint(12.75, 21.675)
+	dist, ok := KthDistance(testPoints{a, {a.Lat + 0.01, a.Lon}}, a, 1, maxKm)
+	assert.True(t, ok)
+	assert.Equal(t, 0.0, dist)
+}
+
+func TestApproximateDistanceSq(t *testing.T) {
+	dist := ApproximateDistance(SFLat, SFLon, ZepLat, ZepLon)
+	assert.InDelta(t, dist*dist, ApproximateDistanceSq(SFLat, SFLon, ZepLat, ZepLon), 1e-6)
+}
+
+func TestNearestWeighted(t *testing.T) {
+	pts := samplePoints()
+	pt := GeoPoint(AlaLat+0.051, AlaLon+0.049)
+	idx, dist := Bestest(pts, pt, 1.0)
+
+	// with no weights it's just the nearest
+	none := func(int) float64 { return 0 }
+	widx, wdist := NearestWeighted(pts, pt, 1.0, none)
+	assert.Equal(t, idx, widx)
+	assert.InDelta(t, dist, wdist, 1e-9)
+
+	// a long queue at the nearest sends us to the next one
+	queued := func(i int) float64 {
+		if i == idx {
+			return 1
+		}
+		return 0
+	}
+	widx, wdist = NearestWeighted(pts, pt, 1.0, queued)
+	assert.NotEqual(t, idx, widx)
+	assert.Greater(t, pt.Distance(pts[widx]), dist)
+	assert.Less(t, wdist, dist+1)
+
+	// nothing in range
+	widx, wdist = NearestWeighted(pts, GeoPoint(0, 0), 1.0, none)
+	assert.Equal(t, pts.Len(), widx)
+	assert.Equal(t, -1.0, wdist)
+}
+
+func TestNearestAhead(t *testing.T) {
+	pt := GeoPoint(AlaLat, AlaLon)
+	behind := GeoPoint(AlaLat-0.01, AlaLon)       // ~1.1km south
+	ahead := GeoPoint(AlaLat+0.011, AlaLon+0.001) // a bit further north
+	pts := testPoints{behind, ahead}
+	sort.Sort(pts)
+
+	idx, dist := Bestest(pts, pt, 5)
+	assert.Equal(t, behind, pts[idx])
+
+	// heading north
+	idx, dist = NearestAhead(pts, pt, 0, 5)
+	assert.Equal(t, ahead, pts[idx])
+	assert.Equal(t, pt.Distance(ahead), dist)
+
+	// heading south (or south west, either way round)
+	for _, heading := range []float64{180, 225, -135} {
+		idx, _ = NearestAhead(pts, pt, heading, 5)
+		assert.Equal(t, behind, pts[idx], "%v", heading)
+	}
+
+	// heading west, there's nothing
+	idx, dist = NearestAhead(pts, GeoPoint(AlaLat, AlaLon-0.05), 270, 5)
+	assert.Equal(t, pts.Len(), idx)
+	assert.Equal(t, -1.0, dist)
+}
+
+func TestAnyWithin(t *testing.T) {
+	pts := samplePoints()
+	pt := GeoPoint(AlaLat+0.051, AlaLon+0.049)
+	for _, km := range []float64{0.5, 2, 10} {
+		idx, ok := AnyWithin(pts, pt, km)
+		assert.True(t, ok)
+		assert.LessOrEqual(t, pt.Distance(pts[idx]), km)
+	}
+	// not the nearest, just the first found
+	idx, _ := AnyWithin(pts, pt, 10)
+	best, _ := Bestest(pts, pt, 10)
+	assert.NotEqual(t, best, idx)
+
+	// the grid is ~1km apart, so there's nothing this close
+	idx, ok := AnyWithin(pts, pt, 0.1)
+	assert.False(t, ok)
+	assert.Equal(t, pts.Len(), idx)
+	_, ok = AnyWithin(pts, GeoPoint(0, 0), 10)
+	assert.False(t, ok)
+
+	// a stored point is within any distance of itself
+	a := GeoPoint(12.75, 21.675)
+	idx, ok = AnyWithin(testPoints{a, {a.Lat + 0.01, a.Lon}}, a, 0.001)
+	assert.True(t, ok)
+	assert.Equal(t, 0, idx)
+}
+
+func TestBatchNearestDistances(t *testing.T) {
+	pts := samplePoints()
+	near := GeoPoint(AlaLat+0.05, AlaLon+0.05)
+	between := GeoPoint(AlaLat+0.05, AlaLon+0.055)
+	queries := []Point{
+		GeoPoint(AlaLat+0.051, AlaLon+0.05),
+		between,
+		GeoPoint(0, 0),
+	}
+	dists := BatchNearestDistances(pts, queries, 1)
+	assert.Len(t, dists, len(queries))
+	assert.InDelta(t, near.Distance(queries[0]), dists[0], 0.0001)
+	assert.InDelta(t, near.Distance(between), dists[1], 0.0001)
+	assert.Equal(t, -1.0, dists[2])
+	assert.Empty(t, BatchNearestDistances(pts, nil, 1))
+}
+
+func TestFarthest(t *testing.T) {
+	pts := samplePoints()
+	box := Rect{{AlaLat + 0.025, AlaLon + 0.025}, {AlaLat + 0.105, AlaLon + 0.105}}
+
+	// from the lower left, the upper right corner of the grid in the box
+	idx, dist := Farthest(pts, GeoPoint(AlaLat, AlaLon), box)
+	corner := GeoPoint(AlaLat+0.1, AlaLon+0.1)
+	assert.Equal(t, corner, pts[idx])
+	assert.Equal(t, GeoPoint(AlaLat, AlaLon).Distance(corner), dist)
+
+	// and the reverse
+	idx, _ = Farthest(pts, GeoPoint(AlaLat+0.2, AlaLon+0.2), box)
+	assert.Equal(t, GeoPoint(AlaLat+0.03, AlaLon+0.03), pts[idx])
+
+	// the only point in the box is the one searched from
+	only := Rect{{AlaLat + 0.045, AlaLon + 0.045}, {AlaLat + 0.055, AlaLon + 0.055}}
+	idx, dist = Farthest(pts, pts.IndexPoint(105), only)
+	assert.Equal(t, 105, idx)
+	assert.InDelta(t, 0, dist, 0.001)
+
+	idx, dist = Farthest(pts, GeoPoint(AlaLat, AlaLon), Rect{{0, 0}, {1, 1}})
+	assert.Equal(t, -1, idx)
+	assert.Equal(t, -1.0, dist)
+}
+
+func TestRingCounts(t *testing.T) {
+	pts := samplePoints()
+	edges := []float64{1, 5, 10}
+	pt := GeoPoint(AlaLat+0.1001, AlaLon+0.1)
+	counts, err := RingCounts(pts, pt, edges)
+	assert.NoError(t, err)
+
+	want := make([]int, len(edges))
+	for _, this := range pts {
+		dist := pt.Distance(this)
+		for ring, edge := range edges {
+			if dist <= edge {
+				want[ring]++
+				break
+			}
+		}
+	}
+	assert.Equal(t, want, counts)
+	// the grid point itself and its east and west neighbors,
+	// as the north and south neighbors are 1.1km away
+	assert.Equal(t, 3, counts[0])
+
+	// a ring can be empty
+	counts, err = RingCounts(pts, pt, []float64{1, 1.05, 5})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{3, 0, want[1]}, counts)
+
+	for _, bad := range [][]float64{nil, {5, 1}, {1, 1}, {-1, 1}} {
+		_, err = RingCounts(pts, pt, bad)
+		assert.Error(t, err, "%v", bad)
+	}
+}
+
+func TestFirstUnsorted(t *testing.T) {
+	pts := samplePoints()
+	m := &MFile{B: encodePoints(pts)}
+	assert.Equal(t, -1, FirstUnsorted(m.NewIter(&pointRecord{})))
+
+	// one record out of place, i.e., behind the record before it
+	moved := append(testPoints{}, pts...)
+	moved[150] = moved[20]
+	m = &MFile{B: encodePoints(moved)}
+	iter := m.NewIter(&pointRecord{})
+	assert.Equal(t, 150, FirstUnsorted(iter))
+	assert.False(t, IsSorted(iter))
+
+	assert.Equal(t, -1, FirstUnsorted(testPoints{}))
+}
+
+func TestNearestExcluding(t *testing.T) {
+	pts := samplePoints()
+	pt := GeoPoint(AlaLat+0.051, AlaLon+0.049)
+	idx, dists := KNearest(pts, pt, 3, 5)
+	assert.Len(t, idx, 3)
+
+	best, dist := NearestExcluding(pts, pt, 5, nil)
+	assert.Equal(t, idx[0], best)
+	assert.Equal(t, dists[0], dist)
+
+	excluded := map[int]bool{idx[0]: true}
+	best, dist = NearestExcluding(pts, pt, 5, excluded)
+	assert.Equal(t, idx[1], best)
+	assert.Equal(t, dists[1], dist)
+
+	excluded[idx[1]] = true
+	best, _ = NearestExcluding(pts, pt, 5, excluded)
+	assert.Equal(t, idx[2], best)
+
+	// the second nearest is out of range
+	best, dist = NearestExcluding(pts, pt, dists[1]-0.01, map[int]bool{idx[0]: true})
+	assert.Equal(t, pts.Len(), best)
+	assert.Equal(t, -1.0, dist)
+}
+
+func TestKNearestAdaptive(t *testing.T) {
+	// a few far flung cities
+	cities := testPoints{
+		GeoPoint(SFLat, SFLon),
+		GeoPoint(ZepLat, ZepLon),
+		GeoPoint(51.5074, -0.1278),   // London
+		GeoPoint(40.7128, -74.0060),  // New York
+		GeoPoint(-33.8688, 151.2093), // Sydney
+		GeoPoint(35.6762, 139.6503),  // Tokyo
+	}
+	sort.Sort(cities)
+	pt := GeoPoint(AlaLat, AlaLon)
+
+	all := make(candidates, 0, len(cities))
+	for i := range cities {
+		all = append(all, candidate{i, pt.Distance(cities[i])})
+	}
+	sort.Stable(all)
+
+	idx, dists := KNearestAdaptive(cities, pt, 3)
+	assert.Len(t, idx, 3)
+	for i := range idx {
+		assert.Equal(t, all[i].idx, idx[i])
+		assert.Equal(t, all[i].dist, dists[i])
+	}
+
+	// asking for more than there are returns them all
+	idx, _ = KNearestAdaptive(cities, pt, 10)
+	assert.Len(t, idx, len(cities))
+
+	idx, _ = KNearestAdaptive(cities, pt, 0)
+	assert.Empty(t, idx)
+	idx, _ = KNearestAdaptive(testPoints{}, pt, 1)
+	assert.Empty(t, idx)
+}